@@ -17,6 +17,495 @@ var ListSettersLong = `
 
 1. Searches for setter comments in input list of resources.
 1. Lists discovered setters and related information.
+
+A setter may declare a default value using the ` + "`" + `${name:-default}` + "`" + ` syntax,
+e.g. ` + "`" + `foo-${tag:-latest}` + "`" + `. The default isn't part of the setter name, it's
+reported as ` + "`" + `Default: latest` + "`" + ` in the ` + "`" + `text` + "`" + ` format and as ` + "`" + `default` + "`" + ` in the
+` + "`" + `json` + "`" + ` format.
+
+A setter which captures nothing in a field matching its pattern, e.g.
+` + "`" + `my-app # kpt-set: my-app${suffix}` + "`" + `, resolves to an empty value rather than
+being skipped. Such setters are marked with ` + "`" + `Empty: true` + "`" + ` in the ` + "`" + `text` + "`" + ` format
+and ` + "`" + `empty` + "`" + ` in the ` + "`" + `json` + "`" + ` format. Fields which don't match the pattern don't
+resolve any setters.
+
+The spacing of the setter comment is matched loosely, e.g. ` + "`" + `#kpt-set:${foo}` + "`" + `,
+` + "`" + `#  kpt-set:  ${foo}` + "`" + ` and comments with tabs instead of spaces are all setter
+comments, as is the case for custom ` + "`" + `setterComment` + "`" + ` identifiers.
+
+The setter comment ends with the last ` + "`" + `${...}` + "`" + ` token and the characters
+directly following it, any text after whitespace is treated as a description
+and ignored, e.g. ` + "`" + `# kpt-set: ${tag}-alpine (legacy)` + "`" + ` uses the ` + "`" + `${tag}-alpine` + "`" + `
+pattern. Text between the tokens is part of the pattern, e.g.
+` + "`" + `# kpt-set: ${first} ${last}` + "`" + ` matches ` + "`" + `jane doe` + "`" + `. Setter comments with an
+unclosed or nested setter reference, e.g. ` + "`" + `# kpt-set: ${foo-${bar}` + "`" + `, are
+reported as warnings naming the file and field, and don't list any setters.
+
+A field can be excluded from the listed setters by following the setter
+pattern with the ` + "`" + `# list-setters:ignore` + "`" + ` directive, e.g.
+` + "`" + `example: my-app # kpt-set: ${app} # list-setters:ignore` + "`" + ` in a ConfigMap
+documenting the package. The field isn't counted for the setter, and isn't
+reported by ` + "`" + `detectUntagged` + "`" + ` either. The directive doesn't affect
+apply-setters.
+
+Setter patterns with adjacent tokens lacking a literal separator, e.g.
+` + "`" + `# kpt-set: ${image}${tag}` + "`" + `, are ambiguous as any value can be split between
+the setters in many ways. Each of them is reported as a warning listing the
+pattern, the file and field, and the adjacent tokens, before any value is
+matched against it. Their setters only resolve if their values are known
+from other fields.
+
+Setter values are the logical values of the fields, without the quotes of
+quoted fields, e.g. ` + "`" + `port: "8080"` + "`" + `, ` + "`" + `port: '8080'` + "`" + ` and ` + "`" + `port: 8080` + "`" + ` all
+resolve ` + "`" + `${port}` + "`" + ` to ` + "`" + `8080` + "`" + `. The quotes are reflected by the ` + "`" + `type` + "`" + ` of the
+setter, ` + "`" + `str` + "`" + ` for the quoted fields and ` + "`" + `int` + "`" + ` for the plain one, while the
+` + "`" + `valueType` + "`" + ` is ` + "`" + `int` + "`" + ` for all of them. A pattern wrapped in quotes, e.g.
+` + "`" + `port: "8080" # kpt-set: "${port}"` + "`" + `, is matched without its quotes if the
+value isn't quoted itself.
+
+Setters also parameterize literal (` + "`" + `|` + "`" + `) and folded (` + "`" + `>` + "`" + `) block scalars, with
+the setter comment following the block indicator, e.g.
+` + "`" + `config: | # kpt-set: ${cfg}` + "`" + `, or on the key if the block starts on the next
+line. The line breaks the block ends with are not part of the setter value,
+line breaks within a literal block are.
+
+Setter comments on YAML anchors, e.g. ` + "`" + `app: &app my-app # kpt-set: ${app}` + "`" + `,
+are counted once at the anchor definition. Aliases, e.g. ` + "`" + `app: *app` + "`" + `, and the
+fields merged with ` + "`" + `<<: *x` + "`" + ` share the content of the anchor, which apply-setters
+sets once, so they aren't counted again and setter comments on aliases are
+ignored.
+
+A setter comment on the key of a mapping, e.g. ` + "`" + `us-east1: # kpt-set: ${region}` + "`" + `,
+parameterizes the key itself. Such setters are listed with the ` + "`" + `key` + "`" + ` type.
+
+Setter values may reference other setters, e.g. a ` + "`" + `gcr.io/${project}/nginx` + "`" + `
+value declared in the Kptfile, which apply-setters only substitutes in another
+pass. Such setters are listed with the setters they depend on, e.g.
+` + "`" + `Depends on: [project]` + "`" + `, or ` + "`" + `dependsOn` + "`" + ` in the ` + "`" + `json` + "`" + ` format. A value
+referencing the setter itself, e.g. the ` + "`" + `${bar}` + "`" + ` value of an unset
+` + "`" + `foo-${bar} # kpt-set: foo-${bar}` + "`" + ` field, is unresolved rather than a
+dependency, see the ` + "`" + `resolve` + "`" + ` mode.
+
+Setters declared in the Kptfile are listed with the pipeline step declaring
+them, e.g. ` + "`" + `Source: pipeline.mutators[0]` + "`" + `, or ` + "`" + `source` + "`" + ` in the ` + "`" + `json` + "`" + ` format.
+Setters inherited from an upstream package are prefixed with the path of its
+Kptfile, e.g. ` + "`" + `base/Kptfile pipeline.mutators[0]` + "`" + `.
+
+Setters parameterizing null fields, e.g. ` + "`" + `field: ~ # kpt-set: ${x}` + "`" + `, are
+listed with the ` + "`" + `null` + "`" + ` value, as are the fields without a value whose setter
+comment follows the key, e.g. ` + "`" + `field: # kpt-set: ${x}` + "`" + `.
+
+Some YAML writers emit the setter comment on the line above the field rather
+than after its value. If the field has no setter comment on its line, the
+comment lines above it are checked too, the last one starting with the
+` + "`" + `setterComment` + "`" + ` is used, e.g.
+
+  # the name of the app
+  # kpt-set: ${app}
+  name: my-app
+
+Resources wrapped in the ` + "`" + `items` + "`" + ` of a ` + "`" + `List` + "`" + ` kind, e.g. ` + "`" + `v1/List` + "`" + ` or
+` + "`" + `ConfigMapList` + "`" + `, are visited as separate resources of the file of the List,
+so that the ` + "`" + `kinds` + "`" + ` option and the resource counts apply to them and the
+field paths are relative to them, e.g. ` + "`" + `metadata.name` + "`" + ` instead of
+` + "`" + `items[0].metadata.name` + "`" + `. Other kinds ending in ` + "`" + `List` + "`" + `, e.g. a custom resource
+whose ` + "`" + `items` + "`" + ` are plain values, are only expanded if all their items are
+resources with an ` + "`" + `apiVersion` + "`" + ` and a ` + "`" + `kind` + "`" + `.
+
+Setters are also discovered in the inline patches of a kustomization, i.e.
+the ` + "`" + `patchesStrategicMerge` + "`" + ` entries and the ` + "`" + `patch` + "`" + ` field of the ` + "`" + `patches` + "`" + ` and
+` + "`" + `patchesJson6902` + "`" + ` entries of ` + "`" + `kustomization.yaml` + "`" + ` or of a resource with the
+` + "`" + `Kustomization` + "`" + ` kind, even though the patches are string values.
+
+Besides the ` + "`" + `configMap` + "`" + ` and ` + "`" + `configPath` + "`" + ` fields, the apply-setters mutator of
+the Kptfile can declare its setters in an inline ` + "`" + `functionConfig` + "`" + ` resource, in
+which case the setters are read from its ` + "`" + `data` + "`" + ` field. Only one of the three
+fields can be declared per mutator.
+
+If multiple apply-setters mutators of the Kptfile declare the same setter, the
+declaration of the last mutator is used and a warning lists the values of all
+the declarations, as this is usually a copy-paste mistake.
+
+If the Kptfile declares an ` + "`" + `upstream` + "`" + ` or ` + "`" + `upstreamLock` + "`" + ` git package, the
+setters declared in the Kptfile of that package are inherited when it's part
+of the input, e.g. a base package in a subdirectory. The upstream package is
+found by its name, which is the last element of the upstream ` + "`" + `directory` + "`" + `, and
+its own upstream is followed in turn. Setters declared downstream override the
+inherited ones. If the upstream Kptfile isn't part of the input, the setters
+which aren't declared in any Kptfile are reported in a warning as they could
+not be resolved.
+
+A warning is reported for each setter declared in the Kptfile as a scalar
+which parameterizes array fields, e.g. a list declared as the comma separated
+string ` + "`" + `a,b` + "`" + ` instead of the YAML list ` + "`" + `[a, b]` + "`" + `, and vice versa.
+
+The warnings are reported as items of the function results with the
+` + "`" + `warning` + "`" + ` severity, so that ` + "`" + `kpt fn render` + "`" + ` and ` + "`" + `kpt fn eval` + "`" + ` show them
+along with the setters. Warnings about a field, e.g. a malformed setter
+pattern, also set the ` + "`" + `file` + "`" + ` and ` + "`" + `field` + "`" + ` paths of the item, and warnings
+about a file, e.g. array values which differ from the Kptfile, the ` + "`" + `file` + "`" + `
+path.
+
+The setters of a single YAML document, e.g. a resource selected in an editor,
+can be listed with the ` + "`" + `snippet` + "`" + ` command of the function binary, which reads
+the document from stdin. The Kptfile isn't read and the document doesn't need
+to be a complete resource. The ` + "`" + `--format` + "`" + ` flag selects the output format.
+
+  $ list-setters snippet < deployment.yaml
+
+The ` + "`" + `diff` + "`" + ` command lists the setters added, removed and changed between two
+inventories rendered in the ` + "`" + `json` + "`" + ` format, e.g. of the base and head renders
+of a package in a pull request. Changed setters report their old and new
+values and the change of their counts, e.g.
+` + "`" + `Changed: Name: tag, Value: 1.0 -> 1.1, Count: 2 -> 3 (+1)` + "`" + `. The ` + "`" + `--format` + "`" + `
+flag selects the ` + "`" + `text` + "`" + ` (default), ` + "`" + `json` + "`" + ` or ` + "`" + `markdown` + "`" + ` format.
+
+  $ list-setters diff base.json head.json --format markdown
+
+Go programs can embed the discovery in their own ` + "`" + `kio.Pipeline` + "`" + `, as the
+` + "`" + `ListSetters` + "`" + ` type of the ` + "`" + `listsetters` + "`" + ` package is a ` + "`" + `kio.Filter` + "`" + `. Its
+` + "`" + `ListFrom` + "`" + ` method reads a ResourceList or a stream of resources from an
+` + "`" + `io.Reader` + "`" + `, and ` + "`" + `ListPackage` + "`" + ` reads a package directory, both writing the
+results to an ` + "`" + `io.Writer` + "`" + `.
+
+### FunctionConfig
+
+` + "`" + `list-setters` + "`" + ` function can optionally be configured using a ConfigMap. The
+options are provided as key-value pairs using the ` + "`" + `data` + "`" + ` field.
+
+  apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: list-setters-fn-config
+  data:
+    format: json
+
+Supported options:
+
+- ` + "`" + `format` + "`" + `: Output format of the results, one of ` + "`" + `text` + "`" + ` (default), ` + "`" + `json` + "`" + `,
+  ` + "`" + `markdown` + "`" + `, ` + "`" + `histogram` + "`" + `, ` + "`" + `dotenv` + "`" + `, ` + "`" + `setters-config` + "`" + `, ` + "`" + `sarif` + "`" + `, ` + "`" + `jsonl` + "`" + ` or
+  ` + "`" + `oneline` + "`" + `.
+  The ` + "`" + `json` + "`" + ` format reports all the setters as a single JSON array of objects
+  with ` + "`" + `name` + "`" + `, ` + "`" + `value` + "`" + `, ` + "`" + `type` + "`" + `, ` + "`" + `count` + "`" + `, ` + "`" + `fieldCount` + "`" + `, ` + "`" + `resourceCount` + "`" + ` and
+  ` + "`" + `files` + "`" + ` keys, array setter values are reported as JSON arrays and ` + "`" + `files` + "`" + `
+  lists the sorted paths of the files where the setter is used. ` + "`" + `fieldCount` + "`" + `
+  is the number of fields parameterized by the setter, ` + "`" + `count` + "`" + ` is an alias of
+  it, and ` + "`" + `resourceCount` + "`" + ` is the number of resources containing those fields.
+  Scalar setters also report ` + "`" + `valueType` + "`" + `, the type their value resolves to as
+  a plain YAML scalar, one of ` + "`" + `int` + "`" + `, ` + "`" + `bool` + "`" + `, ` + "`" + `float` + "`" + `, ` + "`" + `null` + "`" + ` or ` + "`" + `string` + "`" + `. Unlike
+  ` + "`" + `type` + "`" + `, which is the tag of the field, it reveals values such as ` + "`" + `"3"` + "`" + ` which
+  are numbers set as strings.
+  Scalar setters parameterizing fields with different values in the same
+  document are marked with ` + "`" + `inconsistencies` + "`" + `, listing the ` + "`" + `file` + "`" + `, ` + "`" + `resource` + "`" + `
+  and the ` + "`" + `path` + "`" + ` and ` + "`" + `value` + "`" + ` of each of the fields in the document, as only
+  one of the values can be applied. They are reported as ` + "`" + `Inconsistent: true` + "`" + `
+  in the ` + "`" + `text` + "`" + ` format.
+  Scalar setters declared in the Kptfile whose value in the resources differs
+  from the declared value are marked with ` + "`" + `overridden` + "`" + `, which is also
+  reported as ` + "`" + `Overridden: true` + "`" + ` in the ` + "`" + `text` + "`" + ` format.
+  The ` + "`" + `jsonl` + "`" + ` format reports each setter as a separate single line JSON
+  object with the same keys, in the order of the ` + "`" + `sortBy` + "`" + ` option, so that
+  large inventories can be streamed into log pipelines one line at a time
+  instead of parsing a single array. In ` + "`" + `perPackage` + "`" + ` mode each line is a
+  package object, and in ` + "`" + `dryRun` + "`" + ` mode each line is a change.
+  The ` + "`" + `markdown` + "`" + ` format reports the setters as a single GitHub flavored
+  Markdown table with ` + "`" + `Name` + "`" + `, ` + "`" + `Type` + "`" + `, ` + "`" + `Value` + "`" + ` and ` + "`" + `Count` + "`" + ` columns, e.g. to
+  post the setters of a package as a pull request comment. In ` + "`" + `perPackage` + "`" + `
+  mode each package is reported as a table under a ` + "`" + `#### Package: <directory>` + "`" + `
+  heading.
+  The ` + "`" + `histogram` + "`" + ` format reports how many setters are used 0, 1, 2, 3-5 and
+  6 or more times, one line per bucket e.g. ` + "`" + `Count: 3-5, Setters: 2` + "`" + `, which
+  helps to decide which setters to promote to package level parameters.
+  The ` + "`" + `dotenv` + "`" + ` format reports each setter as a ` + "`" + `NAME=value` + "`" + ` line to seed
+  environment variables of shell based tooling. Names are uppercased and the
+  characters other than letters, digits and ` + "`" + `_` + "`" + ` are replaced with ` + "`" + `_` + "`" + `, e.g.
+  ` + "`" + `image-tag` + "`" + ` becomes ` + "`" + `IMAGE_TAG` + "`" + `, and names starting with a digit are
+  prefixed with ` + "`" + `_` + "`" + `. Values containing characters special to
+  the shell are single quoted. Setters whose value still contains a ` + "`" + `${...}` + "`" + `
+  reference are skipped.
+  The ` + "`" + `setters-config` + "`" + ` format reports the setters as the ` + "`" + `data` + "`" + ` block of the
+  ConfigMap of the [apply-setters] function, mapping each setter name to its
+  value, e.g. to bootstrap the apply-setters function config of a package
+  templated by hand. Array setter values are rendered as YAML lists in block
+  strings as apply-setters expects. Setters whose value still contains a
+  ` + "`" + `${...}` + "`" + ` reference are skipped. In ` + "`" + `perPackage` + "`" + ` mode each package is
+  reported as a ` + "`" + `data` + "`" + ` block after a ` + "`" + `# Package: <directory>` + "`" + ` comment.
+  The ` + "`" + `sarif` + "`" + ` format reports the setter problems as a single [SARIF] log, e.g.
+  to upload them to GitHub code scanning so that they show up as annotations
+  in pull requests. Setters marked ` + "`" + `unused` + "`" + ` are reported under the
+  ` + "`" + `unused-setter` + "`" + ` rule at the Kptfile, setters marked ` + "`" + `undeclared` + "`" + ` under the
+  ` + "`" + `undeclared-setter` + "`" + ` rule and scalar setters with conflicting values under the
+  ` + "`" + `conflicting-setter-values` + "`" + ` rule, both at the fields of the setter. The
+  other setter discovery warnings are reported under a
+  ` + "`" + `setter-discovery/<reason>` + "`" + ` rule per reason, e.g.
+  ` + "`" + `setter-discovery/MalformedPattern` + "`" + `, at the file they're about. Line and
+  column numbers are relative to the resource as in ` + "`" + `verbose` + "`" + ` mode, so they
+  only match the file for its first document and the fields of the later
+  documents of multi-document files are located at their file without a
+  region. The ` + "`" + `sarif` + "`" + ` format can't be used with ` + "`" + `perPackage` + "`" + `.
+  The ` + "`" + `oneline` + "`" + ` format reports a single line summarizing the setters, e.g.
+  ` + "`" + `12 setters (9 scalar, 3 array), 2 unused, 1 undeclared` + "`" + `, to be used as the
+  description of a CI status check. The unused and undeclared setters are
+  counted regardless of the ` + "`" + `reportUnused` + "`" + ` and ` + "`" + `reportUndeclared` + "`" + ` options, and
+  the line always has the same fields so that it can be parsed.
+- ` + "`" + `arraySeparator` + "`" + `: Separator joining the values of array setters in the
+  ` + "`" + `dotenv` + "`" + ` format, e.g. ` + "`" + `" "` + "`" + ` to render them space separated. Defaults to ` + "`" + `,` + "`" + `.
+- ` + "`" + `splitSetters` + "`" + `: Comma separated names of scalar setters holding lists as
+  delimited values, e.g. ` + "`" + `hosts` + "`" + ` set to ` + "`" + `a.example.com,b.example.com` + "`" + `. The
+  listed setters are reported as array setters with the split values when
+  their value contains the ` + "`" + `splitDelimiter` + "`" + `. Setters aren't split by default.
+- ` + "`" + `splitDelimiter` + "`" + `: Delimiter splitting the values of the ` + "`" + `splitSetters` + "`" + `.
+  Defaults to ` + "`" + `,` + "`" + `.
+- ` + "`" + `arrayValueField` + "`" + `: Field of the mapping elements of array setters reported
+  as their value, e.g. ` + "`" + `name` + "`" + ` to report a list of containers parameterized by
+  ` + "`" + `containers: # kpt-set: ${containers}` + "`" + ` by the container names. Mapping
+  elements, which have no scalar value, are otherwise reported as their YAML
+  with collapsed whitespace, e.g. ` + "`" + `name: nginx image: nginx:1.21` + "`" + `, and so are
+  the elements without the field. The values declared in the Kptfile are
+  reported the same way.
+- ` + "`" + `reportUnused` + "`" + `: If ` + "`" + `true` + "`" + `, setters declared in the Kptfile which are not
+  used by any resource are marked with the ` + "`" + `unused` + "`" + ` status and reported as
+  warnings. Set ` + "`" + `errorOn: unused` + "`" + ` to fail the function with a non-zero exit
+  code instead, e.g. in CI.
+  Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `reportUndeclared` + "`" + `: If ` + "`" + `true` + "`" + `, setters used by resources which are not
+  declared in the apply-setters function config of the Kptfile are marked with
+  the ` + "`" + `undeclared` + "`" + ` status and reported as warnings, set ` + "`" + `errorOn: undeclared` + "`" + `
+  to fail the function. This helps to catch typos in
+  setter comments. Setters are only checked if the Kptfile declares setters.
+  Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `namePattern` + "`" + `: Regular expression which the setter names must match to be
+  listed e.g. ` + "`" + `^image-.*` + "`" + `. All the setters are listed by default.
+- ` + "`" + `nameRegex` + "`" + `: Regular expression which the names of the listed setters are
+  expected to match, e.g. ` + "`" + `^[a-z0-9]([a-z0-9-]*[a-z0-9])?$` + "`" + `. A warning is
+  reported for each setter name which doesn't match it, so naming standards
+  can be enforced together with ` + "`" + `strict` + "`" + `. Names are not validated by default.
+- ` + "`" + `setterComment` + "`" + `: Prefix of the line comments identifying setters, defaults
+  to ` + "`" + `# kpt-set:` + "`" + `. It must not be empty and should start with ` + "`" + `#` + "`" + `.
+- ` + "`" + `syntax` + "`" + `: Syntax of the setter references in the setter patterns, one of
+  ` + "`" + `${}` + "`" + ` (default), ` + "`" + `[[]]` + "`" + ` or ` + "`" + `{{}}` + "`" + `, e.g. ` + "`" + `{{}}` + "`" + ` for the
+  ` + "`" + `# kpt-set: nginx:{{tag}}` + "`" + ` setter comments of packages templated with other
+  tools. The default values are declared the same way in each syntax, e.g.
+  ` + "`" + `{{tag:-latest}}` + "`" + `, and references of the other syntaxes are treated as
+  literal text.
+- ` + "`" + `preserveOrder` + "`" + `: If ` + "`" + `true` + "`" + `, the values of array setters are reported in the
+  order they appear in the resources or the Kptfile, otherwise they are
+  sorted regardless of where they are discovered. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `verbose` + "`" + `: If ` + "`" + `true` + "`" + `, the locations of the fields parameterized by each
+  setter are included in the results as ` + "`" + `file:line:column` + "`" + ` followed by the
+  path of the field in the resource, e.g.
+  ` + "`" + `deploy.yaml:12:18 spec.template.spec.containers[0].image` + "`" + `. Line and column
+  numbers are relative to the first field of the resource, e.g. its
+  ` + "`" + `apiVersion` + "`" + `, rather than to the function input, so they match the file if
+  the resource starts on its first line without indentation. The fields of
+  the later documents of multi-document files are followed by
+  ` + "`" + ` in document N` + "`" + `, the 0-based index of the document in its file, e.g.
+  ` + "`" + `deploy.yaml:4:9 metadata.name in document 1` + "`" + `, whose lines are relative to
+  that document. The ` + "`" + `json` + "`" + ` format reports the field path as the ` + "`" + `path` + "`" + ` key
+  and the index as the ` + "`" + `document` + "`" + ` key, omitted for the first document. The total size of the
+  values set by each setter, its value length times its count, is also
+  reported as ` + "`" + `Value bytes` + "`" + ` in the ` + "`" + `text` + "`" + ` format and ` + "`" + `valueBytes` + "`" + ` in the
+  ` + "`" + `json` + "`" + ` format, which helps to find large templated values. The values of
+  array setters are summed. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `includeResource` + "`" + `: If ` + "`" + `true` + "`" + `, each location reported by ` + "`" + `verbose` + "`" + ` is
+  followed by the resource owning the field as ` + "`" + `Kind/name.namespace` + "`" + `, e.g.
+  ` + "`" + `test.yaml:5:10 metadata.name (Deployment/foo.prod)` + "`" + `. The ` + "`" + `json` + "`" + ` format reports it as a
+  ` + "`" + `resource` + "`" + ` object with ` + "`" + `apiVersion` + "`" + `, ` + "`" + `kind` + "`" + `, ` + "`" + `name` + "`" + ` and ` + "`" + `namespace` + "`" + ` keys.
+  Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `provenanceAnnotation` + "`" + `: Annotation key whose value on the resource owning
+  each field is appended to the locations reported by ` + "`" + `verbose` + "`" + `, e.g.
+  ` + "`" + `test.yaml:4:9 metadata.name from github.com/example/blueprints/app@v1` + "`" + `, to trace the
+  setters back to the package which introduced them. The ` + "`" + `json` + "`" + ` format reports
+  it as the ` + "`" + `provenance` + "`" + ` key. Fields of resources without the annotation are
+  reported as usual.
+- ` + "`" + `dryRun` + "`" + `: If ` + "`" + `true` + "`" + `, the fields which [apply-setters] would change with the
+  setter values proposed in ` + "`" + `values` + "`" + ` are reported with their ` + "`" + `file` + "`" + `, ` + "`" + `path` + "`" + `,
+  ` + "`" + `old` + "`" + ` and ` + "`" + `new` + "`" + ` values instead of listing the setters. The resources are not
+  modified. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `values` + "`" + `: YAML mapping of setter names to the values proposed in ` + "`" + `dryRun` + "`" + `
+  mode, e.g. ` + "`" + `tag: 1.8.0` + "`" + ` or ` + "`" + `images: [nginx, ubuntu]` + "`" + ` for array setters.
+  Setters may have the same names as the options, e.g. ` + "`" + `namespace` + "`" + `. The
+  function fails if ` + "`" + `values` + "`" + ` is set without ` + "`" + `dryRun` + "`" + `.
+- ` + "`" + `embeddedYAML` + "`" + `: If ` + "`" + `true` + "`" + `, setters are also discovered in YAML documents
+  embedded in string values, e.g. a ConfigMap ` + "`" + `data` + "`" + ` entry holding a
+  configuration file. Only values containing the setter comment which parse
+  as a YAML mapping or sequence are inspected, other values are skipped.
+  Values holding multiple ` + "`" + `---` + "`" + ` separated documents are inspected document by
+  document, malformed documents are skipped with a warning.
+  Locations of embedded fields are relative to the embedded document.
+  Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `includeKptfile` + "`" + `: If ` + "`" + `false` + "`" + `, the Kptfile is not read and setters are only
+  discovered from the setter comments of the resources, so setters declared
+  in the Kptfile but not used are not listed and no warning about a missing
+  Kptfile is reported. The results then include an info item stating that
+  Kptfile discovery is disabled. Defaults to ` + "`" + `true` + "`" + `.
+- ` + "`" + `sortBy` + "`" + `: Order of the listed setters, one of ` + "`" + `name` + "`" + ` (default), ` + "`" + `type` + "`" + `,
+  ` + "`" + `count` + "`" + ` or ` + "`" + `location` + "`" + `. ` + "`" + `type` + "`" + ` lists the scalar setters before the array
+  setters, each sorted by name. ` + "`" + `count` + "`" + ` lists the most used setters first,
+  setters with the same count are sorted by name. A scalar and an array setter
+  sharing a name are sorted by type, so that the order is the same across runs
+  and platforms, e.g. for golden file tests. Unlike the other orders, which
+  list each setter once with its aggregated counts, ` + "`" + `location` + "`" + ` lists each
+  field parameterized by a setter as a separate result with a count of 1 and
+  its location, grouped by file and sorted by field path within each file, to
+  browse the package file by file e.g. in editor integrations. Setters without
+  fields, e.g. unused setters, are listed first. Only the ` + "`" + `text` + "`" + `, ` + "`" + `json` + "`" + ` and
+  ` + "`" + `jsonl` + "`" + ` formats list the fields, the other formats list the setters sorted
+  by their first field.
+- ` + "`" + `kinds` + "`" + `: Comma separated list of ` + "`" + `kind` + "`" + ` or ` + "`" + `apiVersion/kind` + "`" + ` selectors e.g.
+  ` + "`" + `apps/v1/Deployment,Service` + "`" + `. Setters are only discovered from the resources
+  matching any of the selectors, the setters declared in the Kptfile are
+  listed regardless. All the resources are inspected by default.
+- ` + "`" + `changedFiles` + "`" + `: Comma or newline separated paths of the files to discover
+  setters from, relative to the package, e.g. the files changed in a pull
+  request. The Kptfile is still read for the setter declarations, so the
+  setters declared in it are listed regardless. The results then include an
+  info item listing the files discovery is scoped to. All the files are
+  inspected by default.
+- ` + "`" + `includePaths` + "`" + `: Comma or newline separated glob patterns of the files to
+  discover setters from, relative to the package, e.g. ` + "`" + `apps/**` + "`" + `. The pattern
+  segments are matched as in ` + "`" + `path.Match` + "`" + `, and a ` + "`" + `**` + "`" + ` segment matches any
+  number of directories. All the files are inspected by default.
+- ` + "`" + `excludePaths` + "`" + `: Comma or newline separated glob patterns of the files to
+  skip in setter discovery, e.g. ` + "`" + `**/test/**` + "`" + ` to skip the test fixtures or
+  generated directories of the package. Files matching both ` + "`" + `includePaths` + "`" + `
+  and ` + "`" + `excludePaths` + "`" + ` are skipped. The setters declared in the Kptfile are
+  listed regardless of both options.
+- ` + "`" + `namespace` + "`" + `: Namespace of the resources to discover setters from, by their
+  ` + "`" + `metadata.namespace` + "`" + `, e.g. to audit the slice of a team in a multi-tenant
+  package. Cluster scoped resources and the resources of other namespaces are
+  skipped, while the setters declared in the Kptfile are listed regardless.
+  The namespace the discovery is scoped to is reported as an info result.
+- ` + "`" + `ignoreAnnotations` + "`" + `: Comma separated ` + "`" + `key=value` + "`" + ` annotations of the
+  resources to skip in setter discovery, e.g.
+  ` + "`" + `config.kubernetes.io/local-config=true` + "`" + ` so that local-config scaffolding
+  doesn't inflate the setter counts. A key without value matches any value of
+  the annotation. The setters declared by the apply-setters config of the
+  Kptfile are still read, even if it carries one of the annotations.
+- ` + "`" + `summary` + "`" + `: If ` + "`" + `true` + "`" + `, the first result reports the number of listed
+  setters, scalar setters and array setters and the total number of fields
+  parameterized by them, e.g.
+  ` + "`" + `Summary: Setters: 3, Scalar: 2, Array: 1, Occurrences: 3` + "`" + `. In the ` + "`" + `json` + "`" + `
+  format it's reported as an object with ` + "`" + `setters` + "`" + `, ` + "`" + `scalarSetters` + "`" + `,
+  ` + "`" + `arraySetters` + "`" + ` and ` + "`" + `occurrences` + "`" + ` keys. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `anchorPattern` + "`" + `: If ` + "`" + `true` + "`" + `, a setter pattern must match the whole field
+  value, e.g. ` + "`" + `my-app-layer.${env}` + "`" + ` no longer matches
+  ` + "`" + `prefix-my-app-layer.dev` + "`" + `. Anchoring is the safer choice as it avoids
+  deriving setter values from partial matches, it's not enabled by default to
+  keep the existing results stable. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `ignoreCase` + "`" + `: If ` + "`" + `true` + "`" + `, the literal parts of the setter patterns are
+  matched case-insensitively, e.g. ` + "`" + `my-app-layer.${env}` + "`" + ` matches
+  ` + "`" + `My-App-Layer.DEV` + "`" + ` with ` + "`" + `env` + "`" + ` set to ` + "`" + `DEV` + "`" + `. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `perPackage` + "`" + `: If ` + "`" + `true` + "`" + `, the setters of each package are discovered and
+  reported separately. Each resource belongs to the package of the closest
+  Kptfile in its directory or parent directories, and the setters declared in
+  that Kptfile are used for the package. The text format starts each package
+  section with a ` + "`" + `Package: <directory>` + "`" + ` line, the ` + "`" + `json` + "`" + ` format reports an
+  array of objects with ` + "`" + `package` + "`" + ` and ` + "`" + `setters` + "`" + ` keys. Resources outside of
+  any package are reported in the ` + "`" + `.` + "`" + ` package. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `constraints` + "`" + `: YAML mapping of array setter names to the values they are
+  allowed to contain. Each array setter field containing other values is
+  reported as a warning, set ` + "`" + `errorOn: constraints` + "`" + ` to fail the function with
+  a non-zero exit code so that it can be used as a validator.
+
+  ` + "`" + `` + "`" + `` + "`" + `yaml
+  data:
+    constraints: |
+      regions:
+        allowed: [us, eu]
+  ` + "`" + `` + "`" + `` + "`" + `
+- ` + "`" + `detectUntagged` + "`" + `: If ` + "`" + `true` + "`" + `, the fields without a setter comment holding
+  the value of a scalar setter are reported as warnings, e.g. a copy of a
+  value which drifted from its setter. The Kptfile, the apply-setters
+  ` + "`" + `configPath` + "`" + ` files and the annotations whose key contains
+  ` + "`" + `config.kubernetes.io/` + "`" + `, which are added by kpt e.g. the file path and index,
+  are not inspected. The fields are found by comparing values only, so they
+  are reported as heuristic candidate unparameterized fields which may be
+  false positives, e.g. an unrelated port with the value of a ` + "`" + `replicas` + "`" + `
+  setter, and their messages start with ` + "`" + `heuristic:` + "`" + `. They don't fail the
+  function unless ` + "`" + `errorOn` + "`" + ` includes ` + "`" + `untagged` + "`" + `. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `verify` + "`" + `: If ` + "`" + `true` + "`" + `, the listed setter values are substituted in the setter
+  pattern of each field parameterized by them, as apply-setters would apply
+  them, and the fields whose value would change are reported as warnings, e.g.
+  ` + "`" + `re-applying the setters to spec.replicas in test.yaml would change "3" to
+  "4"` + "`" + `. Re-applying the discovered values is expected to be a no-op, so any
+  such field is a pattern which isn't resolved consistently or a value which
+  differs from the one declared in the Kptfile. Set ` + "`" + `errorOn: mismatch` + "`" + ` to
+  fail the function if any field would change. It can't be used with the
+  ` + "`" + `count` + "`" + ` mode. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `debug` + "`" + `: If ` + "`" + `true` + "`" + `, the setter discovery decisions are reported as info
+  results, e.g. ` + "`" + `File: test.yaml, Path: spec.replicas, found setter pattern
+  "${replicas}"` + "`" + `. Each field with a line comment is reported with whether the
+  comment starts with the ` + "`" + `setterComment` + "`" + ` and the setter pattern found in it,
+  along with the setter values resolved from the field value or the reason
+  the comment is ignored, e.g. a setter comment on the key of a scalar field.
+  Resources skipped by the ` + "`" + `kinds` + "`" + `, ` + "`" + `changedFiles` + "`" + `, ` + "`" + `includePaths` + "`" + ` or
+  ` + "`" + `excludePaths` + "`" + ` options are reported too. It helps to diagnose why a setter
+  isn't listed. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `stats` + "`" + `: If ` + "`" + `true` + "`" + `, the number of documents scanned for setters and skipped,
+  and whether a Kptfile was found, are reported before the results, e.g.
+  ` + "`" + `Stats: Documents: 4, Scanned: 3, Skipped: 1, Truncated: 0, Kptfile found:
+  true` + "`" + `. The ` + "`" + `json` + "`" + ` format reports them as a JSON object. Documents are
+  skipped if they don't match the ` + "`" + `kinds` + "`" + `, ` + "`" + `changedFiles` + "`" + `, ` + "`" + `includePaths` + "`" + ` or
+  ` + "`" + `excludePaths` + "`" + ` options, and truncated if they have fields nested deeper than
+  the ` + "`" + `maxDepth` + "`" + ` option. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `mode` + "`" + `: Discovery mode, one of ` + "`" + `full` + "`" + `, the default, ` + "`" + `count` + "`" + ` or ` + "`" + `resolve` + "`" + `.
+  The ` + "`" + `count` + "`" + ` mode only counts the fields parameterized by each setter,
+  without resolving the setter values from the field values, which roughly
+  halves the time taken by large packages. Its output is reduced to the setter
+  names and counts, e.g. ` + "`" + `Name: replicas, Count: 3` + "`" + `, or
+  ` + "`" + `{"name":"replicas","count":3}` + "`" + ` in the ` + "`" + `json` + "`" + ` format, one object per line in
+  the ` + "`" + `jsonl` + "`" + ` format, the only other formats it supports. The ` + "`" + `resolve` + "`" + ` mode
+  reports whether the value of each setter is resolved or still contains a
+  setter reference, e.g. a ` + "`" + `${project}` + "`" + ` placeholder which needs a value before
+  the package is deployable, as ` + "`" + `Name: project, Value: ${project}, Resolved: false` + "`" + `,
+  or ` + "`" + `{"name":"project","value":"${project}","resolved":false}` + "`" + ` in the ` + "`" + `json` + "`" + `
+  format. It supports the same formats as the ` + "`" + `count` + "`" + ` mode. Neither mode can
+  be used with ` + "`" + `dryRun` + "`" + `.
+- ` + "`" + `maxDepth` + "`" + `: Maximum nesting depth of the fields scanned for setters, ` + "`" + `1` + "`" + ` for
+  the top level fields such as ` + "`" + `metadata` + "`" + `, ` + "`" + `2` + "`" + ` for their fields such as
+  ` + "`" + `metadata.name` + "`" + `, and so on. Each sequence element is a level too. Deeper
+  fields, e.g. of deeply nested custom resources, are not scanned, which is
+  reported by the ` + "`" + `debug` + "`" + ` and ` + "`" + `stats` + "`" + ` options. The depth is unlimited by
+  default.
+- ` + "`" + `maxWarnings` + "`" + `: Maximum number of setter discovery warnings listed, e.g. in
+  the ` + "`" + `strict` + "`" + ` mode error, the others are summarized by a single
+  ` + "`" + `and 12 more warnings` + "`" + ` warning to keep the output of pathological packages
+  readable. The ` + "`" + `errorOn` + "`" + ` categories are still checked against all the
+  warnings. The number of warnings is unlimited by default.
+- ` + "`" + `maxValuesShown` + "`" + `: Maximum number of values of array setters shown in the
+  ` + "`" + `text` + "`" + ` and ` + "`" + `markdown` + "`" + ` formats, the others are summarized, e.g.
+  ` + "`" + `[a, b, ... (+498 more)]` + "`" + ` for a setter with 500 values and
+  ` + "`" + `maxValuesShown: 2` + "`" + `, to keep long lists readable. The counts are not
+  affected and the ` + "`" + `json` + "`" + ` and ` + "`" + `jsonl` + "`" + ` formats always report all the values.
+  All the values are shown by default.
+- ` + "`" + `inventoryPath` + "`" + `: Path of a ` + "`" + `setter-inventory` + "`" + ` resource listing the
+  discovered setters, relative to the package, e.g. ` + "`" + `setters-inventory.yaml` + "`" + `.
+  The resource is added to the output so that ` + "`" + `kpt fn render` + "`" + ` materializes it
+  into the package, and the inventory of a previous run found at the path in
+  the input is replaced rather than scanned for setters. The function fails if
+  other resources are at the path. It's annotated with
+  ` + "`" + `config.kubernetes.io/local-config: "true"` + "`" + ` so that it isn't deployed. No
+  inventory is generated by default.
+- ` + "`" + `inventoryKind` + "`" + `: Kind of the ` + "`" + `inventoryPath` + "`" + ` resource, one of ` + "`" + `ConfigMap` + "`" + `,
+  the default, whose ` + "`" + `data` + "`" + ` maps the setter names to their values, or
+  ` + "`" + `SetterInventory` + "`" + `, a ` + "`" + `fn.kpt.dev/v1alpha1` + "`" + ` resource whose ` + "`" + `spec.setters` + "`" + ` are
+  the results in the ` + "`" + `json` + "`" + ` format. In ` + "`" + `perPackage` + "`" + ` mode only
+  ` + "`" + `SetterInventory` + "`" + ` is supported, its ` + "`" + `spec.packages` + "`" + ` are the results of each
+  package.
+- ` + "`" + `strict` + "`" + `: If ` + "`" + `true` + "`" + `, the function fails with a non-zero exit code if any
+  setter discovery warnings are found, e.g. a missing Kptfile or conflicting
+  setter values. Defaults to ` + "`" + `false` + "`" + `.
+- ` + "`" + `errorOn` + "`" + `: Categories of setter problems which fail the function with a
+  non-zero exit code, as a list e.g. ` + "`" + `[unused, conflict]` + "`" + ` or comma separated.
+  The categories are ` + "`" + `unused` + "`" + ` for setters declared in the Kptfile but not used
+  by any resource, ` + "`" + `undeclared` + "`" + ` for setters used by resources but not declared
+  in the Kptfile, ` + "`" + `conflict` + "`" + ` for scalar setters parameterizing fields with
+  different values, ` + "`" + `untagged` + "`" + ` for the fields found by ` + "`" + `detectUntagged` + "`" + `,
+  which must then be set, ` + "`" + `constraints` + "`" + ` for the array setter values not
+  allowed by ` + "`" + `constraints` + "`" + `, and ` + "`" + `mismatch` + "`" + ` for the fields found by ` + "`" + `verify` + "`" + `,
+  which must then be set. Unlike ` + "`" + `strict` + "`" + `, the other warnings don't fail the
+  function. Not set by default.
 `
 var ListSettersExamples = `
 ### Listing setters in a package
@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/GoogleContainerTools/kpt-functions-sdk/go v0.0.0-20210810181223-632b30549de6
+	github.com/spf13/cobra v1.0.0
 	github.com/stretchr/testify v1.7.0
 	sigs.k8s.io/kustomize/kyaml v0.11.0
 )
@@ -21,7 +22,6 @@ require (
 	github.com/monochromegane/go-gitignore v0.0.0-20200626010858-205db1a8cc00 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/spf13/cobra v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/xlab/treeprint v0.0.0-20181112141820-a009c3971eca // indirect
 	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e // indirect
@@ -0,0 +1,61 @@
+package listsetters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AmbiguousPattern is a setter pattern with adjacent setter references
+// without a literal separator, e.g. ${a}${b}, which can split any field
+// value in many ways so that the values of the setters can't be derived
+type AmbiguousPattern struct {
+	// File is the file path of the resource
+	File string
+
+	// Path is the path of the field in the resource e.g. metadata.name
+	Path string
+
+	// Pattern is the setter pattern of the field
+	Pattern string
+
+	// Adjacent are the adjacent setter references e.g. ${a}${b}
+	Adjacent []string
+}
+
+func (a *AmbiguousPattern) Error() string {
+	return fmt.Sprintf("ambiguous setter pattern %q of %s in %s, the setter references %s aren't separated by a literal "+
+		"so their values can't be derived reliably", a.Pattern, a.Path, a.File, strings.Join(a.Adjacent, ""))
+}
+
+// adjacentReferences returns the first run of adjacent setter references
+// of the pattern, nil if the references are all separated by literals
+func adjacentReferences(syntax SetterSyntax, pattern string) []string {
+	locs := syntax.FindReferences(pattern)
+	for i := 0; i+1 < len(locs); i++ {
+		if locs[i+1][0] != locs[i][1] {
+			continue
+		}
+		out := []string{pattern[locs[i][0]:locs[i][1]]}
+		for ; i+1 < len(locs) && locs[i+1][0] == locs[i][1]; i++ {
+			out = append(out, pattern[locs[i+1][0]:locs[i+1][1]])
+		}
+		return out
+	}
+	return nil
+}
+
+// checkAmbiguousPattern records the setter pattern of the scalar field in
+// AmbiguousPatterns and adds a warning if it has adjacent setter references,
+// before any value is matched against it
+func (ls *ListSetters) checkAmbiguousPattern(res *resource, f setterField) {
+	if f.array {
+		return
+	}
+	adjacent := adjacentReferences(ls.syntax(), f.pattern)
+	if adjacent == nil {
+		return
+	}
+	a := &AmbiguousPattern{File: res.filePath, Path: strings.TrimPrefix(f.path, "."), Pattern: f.pattern, Adjacent: adjacent}
+	ls.AmbiguousPatterns = append(ls.AmbiguousPatterns, a)
+	ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: AmbiguousCapture, message: a.Error(), File: a.File, Path: a.Path})
+}
@@ -0,0 +1,276 @@
+package listsetters
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// resource identifies a resource being visited. It's passed down to the
+// functions handling its fields rather than stored on the ListSetters, so
+// that resources can be visited concurrently or in any order.
+type resource struct {
+	// filePath is the file path of the resource
+	filePath string
+
+	// id is the identity of the resource, it includes the file path and the
+	// index of the document so that documents sharing both the file and the
+	// identity e.g. rendered from the same chart template are told apart
+	id string
+
+	// ref is the reference to the resource
+	ref ResourceRef
+
+	// annotations are the annotations of the resource
+	annotations map[string]string
+
+	// lineOffset and columnOffset are the number of lines before the resource
+	// and of columns it's indented by in the input e.g. a ResourceList, they're
+	// subtracted from the positions of its fields so that they're relative
+	// to the resource
+	lineOffset, columnOffset int
+
+	// document is the index of the resource document in its file
+	document int
+}
+
+// newResource returns the resource of the node, snippets without resource
+// metadata or file annotations e.g. from editors aren't attributed to a file
+func newResource(node *yaml.RNode) (*resource, error) {
+	filePath, index, err := kioutil.GetFileAnnotations(node)
+	if err != nil && err != yaml.ErrMissingMetadata {
+		return nil, err
+	}
+	filePath = slashPath(filePath)
+	line, column := offsets(node)
+	// the index is missing from snippets, which are single documents
+	document, _ := strconv.Atoi(index)
+	return &resource{
+		filePath:     filePath,
+		id:           fmt.Sprintf("%s#%s:%s", filePath, index, resourceID(node)),
+		ref:          ResourceRef{APIVersion: node.GetApiVersion(), Kind: node.GetKind(), Name: node.GetName(), Namespace: node.GetNamespace()},
+		annotations:  node.GetAnnotations(),
+		lineOffset:   line,
+		columnOffset: column,
+		document:     document,
+	}, nil
+}
+
+// resourceFields holds the fields tagged with setter comments in a resource.
+// The fields of the resources are collected concurrently and added to the
+// setters sequentially in the order of the resources, so that the results
+// don't depend on the order the resources are visited in.
+type resourceFields struct {
+	// res is the resource of the fields
+	res *resource
+
+	// fields are the tagged fields in document order
+	fields []setterField
+
+	// warnings are the warnings found while collecting the fields
+	warnings []*WarnSetterDiscovery
+
+	// trace holds the discovery decisions made while collecting the fields
+	trace []*TraceEntry
+
+	// truncated are the paths of the fields which are not visited
+	// as they're nested deeper than the maxDepth
+	truncated []string
+}
+
+// setterField is a field tagged with a setter comment
+type setterField struct {
+	// node is the scalar node of the field, or the key node of array setter fields
+	node *yaml.RNode
+
+	// pattern is the setter pattern of the setter comment
+	pattern string
+
+	// valueType is the data type of the scalar value
+	valueType string
+
+	// path is the path of the field
+	path string
+
+	// array is true if the field is parameterized by an array setter
+	array bool
+
+	// elements are the elements of the sequence of array setter fields
+	elements []*yaml.RNode
+}
+
+// fieldCollector is a visitor collecting the tagged fields of a resource,
+// it only reads the options of ls so collectors can run concurrently
+type fieldCollector struct {
+	// setterComment is the prefix of the line comments identifying setters
+	setterComment string
+
+	// syntax identifies the setter references
+	syntax SetterSyntax
+
+	// embeddedYAML visits the YAML documents embedded in string values
+	embeddedYAML bool
+
+	// debug records the discovery decisions
+	debug bool
+
+	// maxDepth is the maximum depth of the visited fields
+	maxDepth int
+
+	// out holds the collected fields
+	out *resourceFields
+}
+
+func (c *fieldCollector) depthLimit() int {
+	return c.maxDepth
+}
+
+func (c *fieldCollector) visitTruncated(_ *yaml.RNode, path string, res *resource) error {
+	c.out.truncated = append(c.out.truncated, path)
+	c.trace(res, path, "field is nested deeper than %s %d and isn't visited", MaxDepthKey, c.maxDepth)
+	return nil
+}
+
+// headPattern returns the setter pattern in the head comment of the key of
+// a field, which is where the YAML parser keeps the comments above a field
+func (c *fieldCollector) headPattern(res *resource, path string, node *yaml.MapNode) string {
+	setterPattern := extractHeadSetterPattern(node.Key.YNode().HeadComment, c.setterComment, c.syntax)
+	if setterPattern != "" {
+		c.trace(res, path+"."+node.Key.YNode().Value, "found setter pattern %q in the head comment", setterPattern)
+	}
+	return setterPattern
+}
+
+// collectFields returns the fields of the resource node tagged with setter comments
+func (ls *ListSetters) collectFields(node *yaml.RNode) (*resourceFields, error) {
+	res, err := newResource(node)
+	if err != nil {
+		return nil, err
+	}
+	out := &resourceFields{res: res}
+	c := &fieldCollector{setterComment: ls.SetterComment, syntax: ls.syntax(), embeddedYAML: ls.EmbeddedYAML, debug: ls.Debug,
+		maxDepth: ls.MaxDepth, out: out}
+	if err := accept(c, node, res); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return out, nil
+}
+
+// collectAll collects the tagged fields of the nodes matching the Kinds and
+// the ChangedFiles using at most Workers goroutines, and counts the scanned
+// and skipped nodes in Stats. The fields are returned in the order of the
+// nodes, nil is returned for the skipped nodes unless Debug is set. The nodes
+// aren't dispatched anymore once ctx is done, and the error of ctx is returned.
+func (ls *ListSetters) collectAll(ctx context.Context, nodes []*yaml.RNode) ([]*resourceFields, error) {
+	out := make([]*resourceFields, len(nodes))
+	errs := make([]error, len(nodes))
+	workers := ls.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(nodes) {
+		workers = len(nodes)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					// drain the dispatched nodes without collecting them
+					continue
+				}
+				out[i], errs[i] = ls.collectFields(nodes[i])
+			}
+		}()
+	}
+	ls.Stats.Documents += len(nodes)
+	for i := range nodes {
+		if ctx.Err() != nil {
+			break
+		}
+		reason := ls.skipReason(nodes[i])
+		if reason == "" {
+			ls.Stats.Scanned++
+			indexes <- i
+			continue
+		}
+		ls.Stats.Skipped++
+		if ls.Debug {
+			out[i] = skippedResource(nodes[i], reason)
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// skippedResource returns the fields of a resource which
+// is skipped, recording the reason it's skipped
+func skippedResource(node *yaml.RNode, reason string) *resourceFields {
+	filePath := nodePath(node)
+	return &resourceFields{res: &resource{filePath: filePath}, trace: []*TraceEntry{{File: filePath, Message: reason}}}
+}
+
+// checkPattern adds a warning and returns false if the setter pattern of the
+// field is malformed e.g. has an unclosed setter reference, which would be
+// parsed to a nonsensical setter name
+func (ls *ListSetters) checkPattern(res *resource, f setterField) bool {
+	v, ok := ls.syntax().(PatternValidator)
+	if !ok {
+		return true
+	}
+	if err := v.ValidatePattern(f.pattern); err != nil {
+		ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: MalformedPattern, message: fmt.Sprintf(
+			"malformed setter pattern %q of %s in %s: %v", f.pattern, strings.TrimPrefix(f.path, "."), res.filePath, err),
+			File: res.filePath, Path: strings.TrimPrefix(f.path, ".")})
+		return false
+	}
+	return true
+}
+
+// addFields adds the setters parameterizing the collected fields of a resource
+func (ls *ListSetters) addFields(rf *resourceFields) {
+	ls.Warnings = append(ls.Warnings, rf.warnings...)
+	ls.Trace = append(ls.Trace, rf.trace...)
+	if len(rf.truncated) > 0 {
+		ls.Stats.Truncated++
+	}
+	for _, f := range rf.fields {
+		if !ls.checkPattern(rf.res, f) {
+			continue
+		}
+		ls.checkAmbiguousPattern(rf.res, f)
+		if ls.Mode == CountMode {
+			ls.countField(f)
+			continue
+		}
+		if ls.Verify {
+			ls.verified = append(ls.verified, verifiedField{res: rf.res, field: f})
+		}
+		if f.array {
+			ls.addArraySetter(rf.res, f)
+		} else {
+			ls.addScalarSetters(rf.res, f.node, f.pattern, f.valueType, f.path)
+		}
+	}
+}
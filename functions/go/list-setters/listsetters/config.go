@@ -0,0 +1,521 @@
+package listsetters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// OutputFormatKey is the functionConfig key selecting the output format
+	OutputFormatKey = "format"
+
+	// ReportUnusedKey is the functionConfig key enabling the report of
+	// setters declared in the Kptfile which are not used by any resource
+	ReportUnusedKey = "reportUnused"
+
+	// ReportUndeclaredKey is the functionConfig key enabling the report of
+	// setters used by resources which are not declared in the Kptfile
+	ReportUndeclaredKey = "reportUndeclared"
+
+	// NamePatternKey is the functionConfig key for the regular expression
+	// which the names of the listed setters must match
+	NamePatternKey = "namePattern"
+
+	// SetterCommentKey is the functionConfig key for the prefix of the
+	// line comments identifying setters
+	SetterCommentKey = "setterComment"
+
+	// PreserveOrderKey is the functionConfig key to report the values
+	// of array setters in document order
+	PreserveOrderKey = "preserveOrder"
+
+	// VerboseKey is the functionConfig key to include the
+	// locations of the setters in the results
+	VerboseKey = "verbose"
+
+	// DryRunKey is the functionConfig key to report the field changes which
+	// applying the setter values provided in ValuesKey would make,
+	// instead of listing the setters
+	DryRunKey = "dryRun"
+
+	// ValuesKey is the functionConfig key for the YAML mapping of setter
+	// names to the values proposed in dry-run mode
+	ValuesKey = "values"
+
+	// EmbeddedYAMLKey is the functionConfig key to discover setters
+	// in YAML documents embedded in string values
+	EmbeddedYAMLKey = "embeddedYAML"
+
+	// IncludeKptfileKey is the functionConfig key to discover
+	// the setters declared in the Kptfile
+	IncludeKptfileKey = "includeKptfile"
+
+	// SortByKey is the functionConfig key selecting the order of the results
+	SortByKey = "sortBy"
+
+	// KindsKey is the functionConfig key for the comma separated kind or
+	// apiVersion/kind selectors of the resources to discover setters from
+	KindsKey = "kinds"
+
+	// SummaryKey is the functionConfig key to report the summary
+	// of the discovered setters before the results
+	SummaryKey = "summary"
+
+	// AnchorPatternKey is the functionConfig key to match the setter
+	// patterns against the whole field value
+	AnchorPatternKey = "anchorPattern"
+
+	// IgnoreCaseKey is the functionConfig key to match the literal
+	// parts of the setter patterns case-insensitively
+	IgnoreCaseKey = "ignoreCase"
+
+	// PerPackageKey is the functionConfig key to report the
+	// setters of each package separately
+	PerPackageKey = "perPackage"
+
+	// ConstraintsKey is the functionConfig key for the YAML mapping of
+	// array setter names to the constraints of their values
+	ConstraintsKey = "constraints"
+
+	// StrictKey is the functionConfig key to fail if
+	// any setter discovery warnings are found
+	StrictKey = "strict"
+
+	// IncludeResourceKey is the functionConfig key to include the
+	// resource owning each field in the verbose locations
+	IncludeResourceKey = "includeResource"
+
+	// NameRegexKey is the functionConfig key for the regular expression
+	// which the names of the discovered setters are validated against
+	NameRegexKey = "nameRegex"
+
+	// DetectUntaggedKey is the functionConfig key to report the fields
+	// holding the value of a setter without a setter comment
+	DetectUntaggedKey = "detectUntagged"
+
+	// VerifyKey is the functionConfig key to report the fields whose
+	// value would be changed by re-applying the discovered setters
+	VerifyKey = "verify"
+
+	// ChangedFilesKey is the functionConfig key for the comma or newline
+	// separated paths of the files to discover setters from
+	ChangedFilesKey = "changedFiles"
+
+	// IncludePathsKey is the functionConfig key for the comma or newline
+	// separated glob patterns of the files to discover setters from
+	IncludePathsKey = "includePaths"
+
+	// ExcludePathsKey is the functionConfig key for the comma or newline
+	// separated glob patterns of the files to skip in setter discovery
+	ExcludePathsKey = "excludePaths"
+
+	// DebugKey is the functionConfig key to report the
+	// setter discovery decisions made for each field
+	DebugKey = "debug"
+
+	// SyntaxKey is the functionConfig key selecting the
+	// syntax of the setter references in setter patterns
+	SyntaxKey = "syntax"
+
+	// StatsKey is the functionConfig key to report the
+	// number of documents scanned and skipped
+	StatsKey = "stats"
+
+	// ModeKey is the functionConfig key selecting the discovery
+	// mode, one of full, count or resolve
+	ModeKey = "mode"
+
+	// ProvenanceAnnotationKey is the functionConfig key for the annotation
+	// of the resources reported with each location in verbose mode
+	ProvenanceAnnotationKey = "provenanceAnnotation"
+
+	// IgnoreAnnotationsKey is the functionConfig key for the comma separated
+	// key=value annotations of the resources to skip in setter discovery
+	IgnoreAnnotationsKey = "ignoreAnnotations"
+
+	// NamespaceKey is the functionConfig key for the namespace
+	// of the resources to discover setters from
+	NamespaceKey = "namespace"
+
+	// ErrorOnKey is the functionConfig key for the categories of setter problems
+	// which fail the discovery, one of unused, undeclared, conflict, untagged,
+	// constraints or mismatch
+	ErrorOnKey = "errorOn"
+
+	// MaxDepthKey is the functionConfig key for the maximum
+	// nesting depth of the fields scanned for setters
+	MaxDepthKey = "maxDepth"
+
+	// MaxWarningsKey is the functionConfig key for the maximum
+	// number of setter discovery warnings listed
+	MaxWarningsKey = "maxWarnings"
+
+	// InventoryPathKey is the functionConfig key for the path of the
+	// inventory resource of the setters appended to the package
+	InventoryPathKey = "inventoryPath"
+
+	// InventoryKindKey is the functionConfig key for the kind of the
+	// inventory resource, one of ConfigMap or SetterInventory
+	InventoryKindKey = "inventoryKind"
+
+	// ArraySeparatorKey is the functionConfig key for the separator
+	// joining the values of array setters in the dotenv format
+	ArraySeparatorKey = "arraySeparator"
+
+	// SplitSettersKey is the functionConfig key for the comma separated
+	// names of the scalar setters reported as array setters
+	SplitSettersKey = "splitSetters"
+
+	// SplitDelimiterKey is the functionConfig key for the delimiter
+	// splitting the values of the split setters
+	SplitDelimiterKey = "splitDelimiter"
+
+	// ArrayValueFieldKey is the functionConfig key for the field of
+	// the mapping elements of array setters reported as their value
+	ArrayValueFieldKey = "arrayValueField"
+
+	// MaxValuesShownKey is the functionConfig key for the maximum
+	// number of values of array setters shown in the text output
+	MaxValuesShownKey = "maxValuesShown"
+)
+
+const (
+	TextOutputFormat          = "text"
+	JSONOutputFormat          = "json"
+	MarkdownOutputFormat      = "markdown"
+	HistogramOutputFormat     = "histogram"
+	DotenvOutputFormat        = "dotenv"
+	SettersConfigOutputFormat = "setters-config"
+	SARIFOutputFormat         = "sarif"
+	JSONLinesOutputFormat     = "jsonl"
+	OnelineOutputFormat       = "oneline"
+)
+
+const (
+	// NameSortOrder sorts the results by setter name
+	NameSortOrder = "name"
+
+	// TypeSortOrder lists the scalar setters before the array
+	// setters, each sorted by setter name
+	TypeSortOrder = "type"
+
+	// CountSortOrder sorts the results by descending count, setters
+	// with the same count are sorted by name
+	CountSortOrder = "count"
+
+	// LocationSortOrder lists a result per field parameterized by a
+	// setter, sorted by file and then by field path
+	LocationSortOrder = "location"
+)
+
+// outputFormats returns the list of supported output formats
+func outputFormats() []string {
+	return []string{TextOutputFormat, JSONOutputFormat, MarkdownOutputFormat, HistogramOutputFormat, DotenvOutputFormat, SettersConfigOutputFormat, SARIFOutputFormat, JSONLinesOutputFormat,
+		OnelineOutputFormat}
+}
+
+// sortOrders returns the list of supported sort orders
+func sortOrders() []string {
+	return []string{NameSortOrder, TypeSortOrder, CountSortOrder, LocationSortOrder}
+}
+
+// Decode decodes the input functionConfig into ListSetters options
+func Decode(rn *yaml.RNode, ls *ListSetters) error {
+	if rn == nil {
+		return nil
+	}
+	dm := rn.GetDataMap()
+	if f, ok := dm[OutputFormatKey]; ok {
+		ls.OutputFormat = f
+	}
+	var err error
+	if ls.ReportUnused, err = getBool(dm, ReportUnusedKey, ls.ReportUnused); err != nil {
+		return err
+	}
+	if ls.ReportUndeclared, err = getBool(dm, ReportUndeclaredKey, ls.ReportUndeclared); err != nil {
+		return err
+	}
+	if ls.PreserveOrder, err = getBool(dm, PreserveOrderKey, ls.PreserveOrder); err != nil {
+		return err
+	}
+	if ls.Verbose, err = getBool(dm, VerboseKey, ls.Verbose); err != nil {
+		return err
+	}
+	if ls.AnchorPattern, err = getBool(dm, AnchorPatternKey, ls.AnchorPattern); err != nil {
+		return err
+	}
+	if ls.IgnoreCase, err = getBool(dm, IgnoreCaseKey, ls.IgnoreCase); err != nil {
+		return err
+	}
+	if ls.Strict, err = getBool(dm, StrictKey, ls.Strict); err != nil {
+		return err
+	}
+	if ls.IncludeResource, err = getBool(dm, IncludeResourceKey, ls.IncludeResource); err != nil {
+		return err
+	}
+	if ls.DetectUntagged, err = getBool(dm, DetectUntaggedKey, ls.DetectUntagged); err != nil {
+		return err
+	}
+	if ls.Verify, err = getBool(dm, VerifyKey, ls.Verify); err != nil {
+		return err
+	}
+	if ls.Debug, err = getBool(dm, DebugKey, ls.Debug); err != nil {
+		return err
+	}
+	if ls.ReportStats, err = getBool(dm, StatsKey, ls.ReportStats); err != nil {
+		return err
+	}
+	if ls.PerPackage, err = getBool(dm, PerPackageKey, ls.PerPackage); err != nil {
+		return err
+	}
+	if ls.Summary, err = getBool(dm, SummaryKey, ls.Summary); err != nil {
+		return err
+	}
+	if ls.IncludeKptfile, err = getBool(dm, IncludeKptfileKey, ls.IncludeKptfile); err != nil {
+		return err
+	}
+	if ls.EmbeddedYAML, err = getBool(dm, EmbeddedYAMLKey, ls.EmbeddedYAML); err != nil {
+		return err
+	}
+	if ls.DryRun, err = getBool(dm, DryRunKey, ls.DryRun); err != nil {
+		return err
+	}
+	if v, ok := dm[ValuesKey]; ok {
+		if !ls.DryRun {
+			return errors.Errorf("%s requires %s", ValuesKey, DryRunKey)
+		}
+		if ls.Overrides, err = parseSetterValues(v); err != nil {
+			return err
+		}
+	}
+	if p, ok := dm[NamePatternKey]; ok {
+		ls.NamePattern = p
+	}
+	if err := ls.compileNamePattern(); err != nil {
+		return err
+	}
+	if r, ok := dm[NameRegexKey]; ok {
+		ls.NameRegex = r
+	}
+	if err := ls.compileNameRegex(); err != nil {
+		return err
+	}
+	if c, ok := dm[SetterCommentKey]; ok {
+		if strings.TrimSpace(c) == "" {
+			return errors.Errorf("%s must not be empty", SetterCommentKey)
+		}
+		if !strings.HasPrefix(c, "#") {
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: InvalidConfig, message: fmt.Sprintf(
+				`%s %q doesn't start with "#", setters are only discovered from comments`, SetterCommentKey, c)})
+		}
+		ls.SetterComment = c
+	}
+	if e, ok := dm[ErrorOnKey]; ok {
+		if ls.ErrorOn, err = parseErrorOn(e); err != nil {
+			return err
+		}
+	}
+	if err := ls.validateErrorOnOptions(); err != nil {
+		return err
+	}
+	if c, ok := dm[ConstraintsKey]; ok {
+		if ls.Constraints, err = parseConstraints(c); err != nil {
+			return err
+		}
+	}
+	if k, ok := dm[KindsKey]; ok {
+		ls.Kinds = nil
+		for _, selector := range strings.Split(k, ",") {
+			if selector = strings.TrimSpace(selector); selector != "" {
+				ls.Kinds = append(ls.Kinds, selector)
+			}
+		}
+	}
+	if n, ok := dm[NamespaceKey]; ok {
+		ls.Namespace = strings.TrimSpace(n)
+	}
+	if f, ok := dm[ChangedFilesKey]; ok {
+		ls.ChangedFiles = splitPaths(f)
+	}
+	if p, ok := dm[IncludePathsKey]; ok {
+		ls.IncludePaths = splitPaths(p)
+		if err := validatePathPatterns(IncludePathsKey, ls.IncludePaths); err != nil {
+			return err
+		}
+	}
+	if p, ok := dm[ExcludePathsKey]; ok {
+		ls.ExcludePaths = splitPaths(p)
+		if err := validatePathPatterns(ExcludePathsKey, ls.ExcludePaths); err != nil {
+			return err
+		}
+	}
+	if a, ok := dm[IgnoreAnnotationsKey]; ok {
+		if ls.IgnoreAnnotations, err = parseIgnoreAnnotations(a); err != nil {
+			return err
+		}
+	}
+	if s, ok := dm[SyntaxKey]; ok {
+		if ls.Syntax, err = lookupSetterSyntax(s); err != nil {
+			return err
+		}
+	}
+	if sep, ok := dm[ArraySeparatorKey]; ok {
+		ls.ArraySeparator = sep
+	}
+	if s, ok := dm[SplitSettersKey]; ok {
+		ls.SplitSetters = nil
+		for _, name := range strings.Split(s, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				ls.SplitSetters = append(ls.SplitSetters, name)
+			}
+		}
+	}
+	if d, ok := dm[SplitDelimiterKey]; ok {
+		ls.SplitDelimiter = d
+	}
+	if f, ok := dm[ArrayValueFieldKey]; ok {
+		ls.ArrayValueField = strings.TrimSpace(f)
+	}
+	if o, ok := dm[SortByKey]; ok {
+		ls.SortBy = o
+	}
+	if err := ls.validateSortBy(); err != nil {
+		return err
+	}
+	if d, ok := dm[MaxDepthKey]; ok {
+		if ls.MaxDepth, err = strconv.Atoi(strings.TrimSpace(d)); err != nil || ls.MaxDepth < 0 {
+			return errors.Errorf("invalid value %q for %q, must be a non-negative integer", d, MaxDepthKey)
+		}
+	}
+	if w, ok := dm[MaxWarningsKey]; ok {
+		if ls.MaxWarnings, err = strconv.Atoi(strings.TrimSpace(w)); err != nil || ls.MaxWarnings < 0 {
+			return errors.Errorf("invalid value %q for %q, must be a non-negative integer", w, MaxWarningsKey)
+		}
+	}
+	if v, ok := dm[MaxValuesShownKey]; ok {
+		if ls.MaxValuesShown, err = strconv.Atoi(strings.TrimSpace(v)); err != nil || ls.MaxValuesShown < 0 {
+			return errors.Errorf("invalid value %q for %q, must be a non-negative integer", v, MaxValuesShownKey)
+		}
+	}
+	if a, ok := dm[ProvenanceAnnotationKey]; ok {
+		ls.ProvenanceAnnotation = strings.TrimSpace(a)
+	}
+	if p, ok := dm[InventoryPathKey]; ok {
+		ls.InventoryPath = strings.TrimSpace(p)
+	}
+	if k, ok := dm[InventoryKindKey]; ok {
+		ls.InventoryKind = k
+	}
+	if err := ls.validateInventoryKind(); err != nil {
+		return err
+	}
+	if m, ok := dm[ModeKey]; ok {
+		ls.Mode = m
+	}
+	if err := ls.validateMode(); err != nil {
+		return err
+	}
+	return ls.validateOutputFormat()
+}
+
+// parseSetterValues parses the YAML mapping of setter names to proposed values
+// e.g. `tag: 1.8.0`, array values are kept as YAML e.g. `images: [nginx, ubuntu]`
+func parseSetterValues(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	rn, err := yaml.Parse(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid %s: %v", ValuesKey, err)
+	}
+	if rn.IsNilOrEmpty() {
+		return out, nil
+	}
+	if rn.YNode().Kind != yaml.MappingNode {
+		return nil, errors.Errorf("invalid %s: must be a mapping of setter names to values", ValuesKey)
+	}
+	err = rn.VisitFields(func(node *yaml.MapNode) error {
+		if node.Value.YNode().Kind == yaml.ScalarNode {
+			out[node.Key.YNode().Value] = node.Value.YNode().Value
+			return nil
+		}
+		v, err := node.Value.String()
+		if err != nil {
+			return err
+		}
+		out[node.Key.YNode().Value] = strings.TrimSuffix(v, "\n")
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Errorf("invalid %s: %v", ValuesKey, err)
+	}
+	return out, nil
+}
+
+// compileNamePattern compiles the NamePattern regular expression
+func (ls *ListSetters) compileNamePattern() error {
+	ls.nameRegex = nil
+	if ls.NamePattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(ls.NamePattern)
+	if err != nil {
+		return errors.Errorf("invalid %s %q: %v", NamePatternKey, ls.NamePattern, err)
+	}
+	ls.nameRegex = re
+	return nil
+}
+
+// compileNameRegex compiles the NameRegex regular expression
+func (ls *ListSetters) compileNameRegex() error {
+	ls.nameValidator = nil
+	if ls.NameRegex == "" {
+		return nil
+	}
+	re, err := regexp.Compile(ls.NameRegex)
+	if err != nil {
+		return errors.Errorf("invalid %s %q: %v", NameRegexKey, ls.NameRegex, err)
+	}
+	ls.nameValidator = re
+	return nil
+}
+
+// getBool parses the boolean value of key in the data map,
+// def is returned if the key is not present
+func getBool(dm map[string]string, key string, def bool) (bool, error) {
+	v, ok := dm[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, errors.Errorf("invalid value %q for %q, must be a boolean", v, key)
+	}
+	return b, nil
+}
+
+// validateOutputFormat validates the configured output format
+func (ls *ListSetters) validateOutputFormat() error {
+	if ls.OutputFormat == SARIFOutputFormat && ls.PerPackage {
+		return errors.Errorf("%s %q can't be used with %s", OutputFormatKey, SARIFOutputFormat, PerPackageKey)
+	}
+	for _, f := range outputFormats() {
+		if ls.OutputFormat == f {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid output format %q, must be one of %q", ls.OutputFormat, outputFormats())
+}
+
+// validateSortBy validates the configured sort order
+func (ls *ListSetters) validateSortBy() error {
+	for _, o := range sortOrders() {
+		if ls.SortBy == o {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid %s %q, must be one of %q", SortByKey, ls.SortBy, sortOrders())
+}
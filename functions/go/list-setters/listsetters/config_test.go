@@ -0,0 +1,734 @@
+package listsetters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestDecode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		config   string
+		expected ListSetters
+		errMsg   string
+	}{
+		{
+			name: "default format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat},
+		},
+		{
+			name: "json format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  format: json
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: JSONOutputFormat},
+		},
+		{
+			name: "boolean options",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  reportUnused: "true"
+  reportUndeclared: "true"
+  preserveOrder: "true"
+  verbose: "true"
+  embeddedYAML: "true"
+  summary: "true"
+  anchorPattern: "true"
+  ignoreCase: "true"
+  perPackage: "true"
+  strict: "true"
+  includeResource: "true"
+  detectUntagged: "true"
+  debug: "true"
+  stats: "true"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ReportUnused: true, ReportUndeclared: true, PreserveOrder: true, Verbose: true, EmbeddedYAML: true, Summary: true,
+				AnchorPattern: true, IgnoreCase: true, PerPackage: true, Strict: true, IncludeResource: true, DetectUntagged: true, Debug: true, ReportStats: true},
+		},
+		{
+			name: "name pattern",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  namePattern: ^image-.*
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, NamePattern: "^image-.*"},
+		},
+		{
+			name: "invalid name pattern",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  namePattern: image-(
+`,
+			errMsg: "invalid namePattern \"image-(\": error parsing regexp: missing closing ): `image-(`",
+		},
+		{
+			name: "name regex",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  nameRegex: ^[a-z0-9]([a-z0-9-]*[a-z0-9])?$
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, NameRegex: "^[a-z0-9]([a-z0-9-]*[a-z0-9])?$"},
+		},
+		{
+			name: "invalid name regex",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  nameRegex: "[a-z"
+`,
+			errMsg: "invalid nameRegex \"[a-z\": error parsing regexp: missing closing ]: `[a-z`",
+		},
+		{
+			name: "setter comment",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  setterComment: "# vendor-set: "
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, SetterComment: "# vendor-set: "},
+		},
+		{
+			name: "setter comment without comment prefix",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  setterComment: "vendor-set: "
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, SetterComment: "vendor-set: ",
+				Warnings: []*WarnSetterDiscovery{{Reason: InvalidConfig, message: `setterComment "vendor-set: " doesn't start with "#", setters are only discovered from comments`}}},
+		},
+		{
+			name: "empty setter comment",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  setterComment: " "
+`,
+			errMsg: "setterComment must not be empty",
+		},
+		{
+			name: "exclude kptfile",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  includeKptfile: "false"
+`,
+			expected: ListSetters{OutputFormat: TextOutputFormat},
+		},
+		{
+			name: "dry run",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  dryRun: "true"
+  format: json
+  values: |
+    tag: 1.8.0
+    images: [nginx, ubuntu]
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: JSONOutputFormat, DryRun: true,
+				Overrides: map[string]string{"tag": "1.8.0", "images": "[nginx, ubuntu]"}},
+		},
+		{
+			name: "dry run with option named setters",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  dryRun: "true"
+  values: |
+    mode: fast
+    format: yaml
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, DryRun: true,
+				Overrides: map[string]string{"mode": "fast", "format": "yaml"}},
+		},
+		{
+			name: "invalid dry run values",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  dryRun: "true"
+  values: "[tag]"
+`,
+			errMsg: "invalid values: must be a mapping of setter names to values",
+		},
+		{
+			name: "dry run values without dry run",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  values: |
+    tag: 1.8.0
+`,
+			errMsg: "values requires dryRun",
+		},
+		{
+			name: "dotenv format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  format: dotenv
+  arraySeparator: " "
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: DotenvOutputFormat, ArraySeparator: " "},
+		},
+		{
+			name: "split setters",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  splitSetters: "hosts, zones,"
+  splitDelimiter: ";"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, SplitSetters: []string{"hosts", "zones"}, SplitDelimiter: ";"},
+		},
+		{
+			name: "array value field",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  arrayValueField: name
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ArrayValueField: "name"},
+		},
+		{
+			name: "syntax",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  syntax: "[[]]"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, Syntax: DoubleBracketSyntax},
+		},
+		{
+			name: "invalid syntax",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  syntax: "<>"
+`,
+			errMsg: `invalid syntax "<>", must be one of ["${}" "[[]]" "{{}}"]`,
+		},
+		{
+			name: "invalid boolean",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  reportUnused: "yes"
+`,
+			errMsg: `invalid value "yes" for "reportUnused", must be a boolean`,
+		},
+		{
+			name: "kinds",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  kinds: "apps/v1/Deployment, Service,"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, Kinds: []string{"apps/v1/Deployment", "Service"}},
+		},
+		{
+			name: "changed files",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  changedFiles: |
+    deployment.yaml
+    ./base/service.yaml,
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ChangedFiles: []string{"deployment.yaml", "base/service.yaml"}},
+		},
+		{
+			name: "include and exclude paths",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  includePaths: apps/**, ./base/*.yaml
+  excludePaths: |
+    **/test/**
+    generated/**
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, IncludePaths: []string{"apps/**", "base/*.yaml"},
+				ExcludePaths: []string{"**/test/**", "generated/**"}},
+		},
+		{
+			name: "inventory",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  inventoryPath: setters-inventory.yaml
+  inventoryKind: SetterInventory
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, InventoryPath: "setters-inventory.yaml", InventoryKind: SetterInventoryKind},
+		},
+		{
+			name: "invalid inventory kind",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  inventoryPath: setters-inventory.yaml
+  inventoryKind: Secret
+`,
+			errMsg: `invalid inventoryKind "Secret", must be one of ["ConfigMap" "SetterInventory"]`,
+		},
+		{
+			name: "per package ConfigMap inventory",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  perPackage: "true"
+  inventoryPath: setters-inventory.yaml
+  inventoryKind: ConfigMap
+`,
+			errMsg: `inventoryKind "ConfigMap" can't hold the setters of each package, use "SetterInventory" with perPackage`,
+		},
+		{
+			name: "max depth",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxDepth: "3"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, MaxDepth: 3},
+		},
+		{
+			name: "max warnings",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxWarnings: "10"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, MaxWarnings: 10},
+		},
+		{
+			name: "invalid max warnings",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxWarnings: "-1"
+`,
+			errMsg: `invalid value "-1" for "maxWarnings", must be a non-negative integer`,
+		},
+		{
+			name: "max values shown",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxValuesShown: "20"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, MaxValuesShown: 20},
+		},
+		{
+			name: "invalid max values shown",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxValuesShown: all
+`,
+			errMsg: `invalid value "all" for "maxValuesShown", must be a non-negative integer`,
+		},
+		{
+			name: "sarif format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  format: sarif
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: SARIFOutputFormat},
+		},
+		{
+			name: "per package sarif format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  format: sarif
+  perPackage: "true"
+`,
+			errMsg: `format "sarif" can't be used with perPackage`,
+		},
+		{
+			name: "error on list",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: "[unused, conflict]"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ErrorOn: []string{"unused", "conflict"}},
+		},
+		{
+			name: "error on comma separated",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: "undeclared, conflict"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ErrorOn: []string{"undeclared", "conflict"}},
+		},
+		{
+			name: "invalid error on category",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: "[unused, typo]"
+`,
+			errMsg: `invalid errorOn category "typo", must be one of ["unused" "undeclared" "conflict" "untagged" "constraints" "mismatch"]`,
+		},
+		{
+			name: "error on untagged without detection",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: untagged
+`,
+			errMsg: `errorOn category "untagged" requires detectUntagged`,
+		},
+		{
+			name: "error on mismatch without verify",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: mismatch
+`,
+			errMsg: `errorOn category "mismatch" requires verify`,
+		},
+		{
+			name: "ignore annotations",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  ignoreAnnotations: config.kubernetes.io/local-config="true", example.com/scaffold
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat,
+				IgnoreAnnotations: map[string]string{"config.kubernetes.io/local-config": "true", "example.com/scaffold": ""}},
+		},
+		{
+			name: "namespace",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  namespace: team-a
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, Namespace: "team-a"},
+		},
+		{
+			name: "invalid ignore annotations",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  ignoreAnnotations: =true
+`,
+			errMsg: `invalid ignoreAnnotations entry "=true", the annotation key must not be empty`,
+		},
+		{
+			name: "provenance annotation",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  provenanceAnnotation: " example.com/source "
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, ProvenanceAnnotation: "example.com/source"},
+		},
+		{
+			name: "count mode",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  mode: count
+  format: json
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: JSONOutputFormat, Mode: CountMode},
+		},
+		{
+			name: "invalid mode",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  mode: fast
+`,
+			errMsg: `invalid mode "fast", must be one of ["full" "count" "resolve"]`,
+		},
+		{
+			name: "count mode format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  mode: count
+  format: markdown
+`,
+			errMsg: `mode "count" only supports the "text", "json" and "jsonl" output formats`,
+		},
+		{
+			name: "verify",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  verify: "true"
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, Verify: true},
+		},
+		{
+			name: "count mode with verify",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  mode: count
+  verify: "true"
+`,
+			errMsg: `mode "count" can't be used with verify`,
+		},
+		{
+			name: "invalid max depth",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxDepth: "-1"
+`,
+			errMsg: `invalid value "-1" for "maxDepth", must be a non-negative integer`,
+		},
+		{
+			name: "invalid exclude paths",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  excludePaths: "test/[a-"
+`,
+			errMsg: "invalid excludePaths pattern \"test/[a-\": syntax error in pattern",
+		},
+		{
+			name: "constraints",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  constraints: |
+    regions:
+      allowed: [us, eu]
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat,
+				Constraints: map[string]SetterConstraint{"regions": {Allowed: []string{"us", "eu"}}}},
+		},
+		{
+			name: "invalid constraints",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  constraints: "regions: [us, eu]"
+`,
+			errMsg: "invalid constraints: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into listsetters.SetterConstraint",
+		},
+		{
+			name: "sort by count",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  sortBy: count
+`,
+			expected: ListSetters{IncludeKptfile: true, OutputFormat: TextOutputFormat, SortBy: CountSortOrder},
+		},
+		{
+			name: "invalid sort order",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  sortBy: size
+`,
+			errMsg: `invalid sortBy "size", must be one of ["name" "type" "count" "location"]`,
+		},
+		{
+			name: "invalid format",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  format: yaml
+`,
+			errMsg: `invalid output format "yaml", must be one of ["text" "json" "markdown" "histogram" "dotenv" "setters-config" "sarif" "jsonl" "oneline"]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			err := Decode(yaml.MustParse(test.config), &ls)
+			if test.errMsg != "" {
+				require.EqualError(t, err, test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected.OutputFormat, ls.OutputFormat)
+			if test.expected.SortBy != "" {
+				require.Equal(t, test.expected.SortBy, ls.SortBy)
+			} else {
+				require.Equal(t, NameSortOrder, ls.SortBy)
+			}
+			require.Equal(t, test.expected.ReportUnused, ls.ReportUnused)
+			require.Equal(t, test.expected.ReportUndeclared, ls.ReportUndeclared)
+			require.Equal(t, test.expected.PreserveOrder, ls.PreserveOrder)
+			require.Equal(t, test.expected.Verbose, ls.Verbose)
+			require.Equal(t, test.expected.EmbeddedYAML, ls.EmbeddedYAML)
+			require.Equal(t, test.expected.IncludeKptfile, ls.IncludeKptfile)
+			require.Equal(t, test.expected.Summary, ls.Summary)
+			require.Equal(t, test.expected.AnchorPattern, ls.AnchorPattern)
+			require.Equal(t, test.expected.IgnoreCase, ls.IgnoreCase)
+			require.Equal(t, test.expected.PerPackage, ls.PerPackage)
+			require.Equal(t, test.expected.Strict, ls.Strict)
+			require.Equal(t, test.expected.IncludeResource, ls.IncludeResource)
+			require.Equal(t, test.expected.DetectUntagged, ls.DetectUntagged)
+			require.Equal(t, test.expected.Debug, ls.Debug)
+			require.Equal(t, test.expected.ReportStats, ls.ReportStats)
+			require.Equal(t, test.expected.DryRun, ls.DryRun)
+			require.Equal(t, test.expected.Overrides, ls.Overrides)
+			require.Equal(t, test.expected.NamePattern, ls.NamePattern)
+			require.Equal(t, test.expected.NameRegex, ls.NameRegex)
+			if test.expected.ArraySeparator != "" {
+				require.Equal(t, test.expected.ArraySeparator, ls.ArraySeparator)
+			} else {
+				require.Equal(t, DefaultArraySeparator, ls.ArraySeparator)
+			}
+			require.Equal(t, test.expected.Kinds, ls.Kinds)
+			require.Equal(t, test.expected.Syntax, ls.Syntax)
+			require.Equal(t, test.expected.ChangedFiles, ls.ChangedFiles)
+			require.Equal(t, test.expected.IncludePaths, ls.IncludePaths)
+			require.Equal(t, test.expected.ExcludePaths, ls.ExcludePaths)
+			require.Equal(t, test.expected.InventoryPath, ls.InventoryPath)
+			require.Equal(t, test.expected.MaxDepth, ls.MaxDepth)
+			require.Equal(t, test.expected.MaxWarnings, ls.MaxWarnings)
+			require.Equal(t, test.expected.Mode, ls.Mode)
+			require.Equal(t, test.expected.ProvenanceAnnotation, ls.ProvenanceAnnotation)
+			require.Equal(t, test.expected.ErrorOn, ls.ErrorOn)
+			require.Equal(t, test.expected.IgnoreAnnotations, ls.IgnoreAnnotations)
+			require.Equal(t, test.expected.Namespace, ls.Namespace)
+			require.Equal(t, test.expected.Verify, ls.Verify)
+			require.Equal(t, test.expected.SplitSetters, ls.SplitSetters)
+			require.Equal(t, test.expected.SplitDelimiter, ls.SplitDelimiter)
+			require.Equal(t, test.expected.ArrayValueField, ls.ArrayValueField)
+			require.Equal(t, test.expected.MaxValuesShown, ls.MaxValuesShown)
+			require.Equal(t, test.expected.InventoryKind, ls.InventoryKind)
+			require.Equal(t, test.expected.Constraints, ls.Constraints)
+			require.Equal(t, test.expected.Warnings, ls.Warnings)
+			if test.expected.SetterComment != "" {
+				require.Equal(t, test.expected.SetterComment, ls.SetterComment)
+			} else {
+				require.Equal(t, SetterCommentIdentifier, ls.SetterComment)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package listsetters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SetterConstraint restricts the values of an array setter
+type SetterConstraint struct {
+	// Allowed are the values the array setter may contain
+	Allowed []string `yaml:"allowed"`
+}
+
+// ConstraintViolation is an array setter field containing values
+// which are not allowed by the SetterConstraint of the setter
+type ConstraintViolation struct {
+	// Setter is the name of the array setter
+	Setter string
+
+	// File is the file path of the resource
+	File string
+
+	// Disallowed are the sorted values which are not allowed
+	Disallowed []string
+
+	// Allowed are the values allowed by the constraint
+	Allowed []string
+}
+
+func (v *ConstraintViolation) Error() string {
+	return fmt.Sprintf("array setter %q in %s has disallowed values [%s], allowed values are [%s]",
+		v.Setter, v.File, strings.Join(v.Disallowed, ", "), strings.Join(v.Allowed, ", "))
+}
+
+// parseConstraints parses the YAML mapping of setter names to constraints
+// e.g. `regions: {allowed: [us, eu]}`
+func parseConstraints(s string) (map[string]SetterConstraint, error) {
+	constraints := make(map[string]SetterConstraint)
+	if err := yaml.Unmarshal([]byte(s), &constraints); err != nil {
+		return nil, errors.Errorf("invalid %s: %v", ConstraintsKey, err)
+	}
+	return constraints, nil
+}
+
+// checkConstraint records a ConstraintViolation if the values of
+// the array setter in the current resource are not allowed
+func (ls *ListSetters) checkConstraint(res *resource, name string, values []string) {
+	c, ok := ls.Constraints[name]
+	if !ok {
+		return
+	}
+	disallowed := difference(values, c.Allowed)
+	if len(disallowed) == 0 {
+		return
+	}
+	allowed := append([]string(nil), c.Allowed...)
+	sort.Strings(allowed)
+	ls.Violations = append(ls.Violations, &ConstraintViolation{
+		Setter:     name,
+		File:       res.filePath,
+		Disallowed: disallowed,
+		Allowed:    allowed,
+	})
+}
@@ -0,0 +1,104 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+const (
+	// FullMode discovers the setters along with their values and files
+	FullMode = "full"
+
+	// CountMode only counts the fields parameterized by each setter, skipping
+	// the resolution of the setter values from the field values
+	CountMode = "count"
+
+	// ResolveMode discovers the setters and only reports whether the value
+	// of each of them is resolved or still contains a setter reference
+	ResolveMode = "resolve"
+)
+
+// modes returns the supported discovery modes
+func modes() []string {
+	return []string{FullMode, CountMode, ResolveMode}
+}
+
+// validateMode validates the configured discovery mode
+func (ls *ListSetters) validateMode() error {
+	switch ls.Mode {
+	case "", FullMode:
+		return nil
+	case CountMode, ResolveMode:
+		if ls.DryRun {
+			return errors.Errorf("%s %q can't be used with %s", ModeKey, ls.Mode, DryRunKey)
+		}
+		if ls.Mode == CountMode && ls.Verify {
+			return errors.Errorf("%s %q can't be used with %s", ModeKey, CountMode, VerifyKey)
+		}
+		if ls.OutputFormat != TextOutputFormat && ls.OutputFormat != JSONOutputFormat && ls.OutputFormat != JSONLinesOutputFormat {
+			return errors.Errorf("%s %q only supports the %q, %q and %q output formats",
+				ModeKey, ls.Mode, TextOutputFormat, JSONOutputFormat, JSONLinesOutputFormat)
+		}
+		return nil
+	}
+	return errors.Errorf("invalid %s %q, must be one of %q", ModeKey, ls.Mode, modes())
+}
+
+// countField counts the setters referenced in the setter pattern of the
+// field, the pattern isn't matched against the field value
+func (ls *ListSetters) countField(f setterField) {
+	if f.array {
+		name, _ := ls.syntax().ParseReference(f.pattern)
+		if _, ok := ls.ArraySetters[name]; !ok {
+			ls.ArraySetters[name] = &ArraySetter{Name: name, Files: make(map[string]int), Resources: make(map[string]int)}
+		}
+		ls.ArraySetters[name].Count++
+		return
+	}
+	for _, loc := range ls.syntax().FindReferences(f.pattern) {
+		name, _ := ls.syntax().ParseReference(f.pattern[loc[0]:loc[1]])
+		if _, ok := ls.ScalarSetters[name]; !ok {
+			ls.ScalarSetters[name] = &ScalarSetter{Name: name, Type: f.valueType, Files: make(map[string]int), Resources: make(map[string]int)}
+		}
+		ls.ScalarSetters[name].Count++
+	}
+}
+
+// SetterCount is the number of fields parameterized by a setter
+type SetterCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (c SetterCount) String() string {
+	return fmt.Sprintf("Name: %s, Count: %d", c.Name, c.Count)
+}
+
+// formatCounts renders the counts of the results in CountMode
+func (ls *ListSetters) formatCounts(rs []*Result) ([]string, error) {
+	counts := make([]SetterCount, len(rs))
+	for i, r := range rs {
+		counts[i] = SetterCount{Name: r.Name, Count: r.Count}
+	}
+	if ls.OutputFormat == JSONOutputFormat {
+		b, err := json.Marshal(counts)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	}
+	if ls.OutputFormat == JSONLinesOutputFormat {
+		values := make([]interface{}, len(counts))
+		for i := range counts {
+			values[i] = counts[i]
+		}
+		return jsonLines(values)
+	}
+	out := make([]string, len(counts))
+	for i := range counts {
+		out[i] = counts[i].String()
+	}
+	return out, nil
+}
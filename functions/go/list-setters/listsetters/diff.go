@@ -0,0 +1,90 @@
+package listsetters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Change is a field value which would be changed by applying the Overrides
+type Change struct {
+	// File is the file path of the resource
+	File string `json:"file"`
+
+	// Path is the path to the field, path elements are separated by '.'
+	Path string `json:"path"`
+
+	// Old is the current value of the field
+	Old string `json:"old"`
+
+	// New is the value of the field after applying the Overrides
+	New string `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("File: %s, Path: %s, Old: %s, New: %s", c.File, c.Path, c.Old, c.New)
+}
+
+// addScalarChange records the change of the scalar field parameterized by pattern,
+// values are the current setter values derived from the field. The setters which
+// are not overridden keep their current values or else their default values, the
+// change is skipped if any of them can't be derived.
+func (ls *ListSetters) addScalarChange(res *resource, pattern string, values map[string]string, node *yaml.RNode, path string) {
+	overridden := false
+	resolved := true
+	newValue := replaceReferences(ls.syntax(), pattern, func(s string) string {
+		name, def := ls.syntax().ParseReference(s)
+		if v, ok := ls.Overrides[name]; ok {
+			overridden = true
+			return v
+		}
+		if v, ok := values[name]; ok {
+			return v
+		}
+		if def != "" {
+			return def
+		}
+		resolved = false
+		return s
+	})
+	if !overridden || !resolved || newValue == scalarValue(node) {
+		return
+	}
+	ls.Changes = append(ls.Changes, Change{
+		File: res.filePath,
+		Path: strings.TrimPrefix(path, "."),
+		Old:  scalarValue(node),
+		New:  newValue,
+	})
+}
+
+// addArrayChange records the change of the sequence field at path with elements
+// parameterized by the array setter name, the proposed value must be an array
+func (ls *ListSetters) addArrayChange(res *resource, name string, elements []*yaml.RNode, path string) {
+	v, ok := ls.Overrides[name]
+	if !ok {
+		return
+	}
+	newValues, err := getArraySetterFieldValues(v, ls.ArrayValueField)
+	if err != nil {
+		ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: InvalidValue, message: fmt.Sprintf(
+			"value %q of array setter %q is not an array", v, name)})
+		return
+	}
+	oldValues := make([]string, len(elements))
+	for i := range elements {
+		oldValues[i] = ls.elementValue(elements[i])
+	}
+	oldValue := fmt.Sprintf("[%s]", strings.Join(oldValues, ", "))
+	newValue := fmt.Sprintf("[%s]", strings.Join(newValues, ", "))
+	if newValue == oldValue {
+		return
+	}
+	ls.Changes = append(ls.Changes, Change{
+		File: res.filePath,
+		Path: strings.TrimPrefix(path, "."),
+		Old:  oldValue,
+		New:  newValue,
+	})
+}
@@ -0,0 +1,59 @@
+package listsetters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultArraySeparator is the default separator joining
+// the values of array setters in the dotenv format
+const DefaultArraySeparator = ","
+
+// dotenvUnsafeName matches the characters which are not allowed in variable names
+var dotenvUnsafeName = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// dotenvSafeValue matches the values which don't need to be quoted in a shell
+var dotenvSafeValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,-]*$`)
+
+// dotenvLines renders each result as a NAME=value line, array setter values
+// are joined with the ArraySeparator. Setters with unresolved values, which
+// still contain a setter reference e.g. ${...}, are skipped.
+func (ls *ListSetters) dotenvLines(rs []*Result) []string {
+	sep := ls.ArraySeparator
+	if sep == "" {
+		sep = DefaultArraySeparator
+	}
+	var out []string
+	for _, r := range rs {
+		value := r.Value
+		if r.Type == ArraySetterType {
+			value = strings.Join(r.Values, sep)
+		}
+		if ls.syntax().IsUnresolved(value) {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s=%s", dotenvName(r.Name), dotenvValue(value)))
+	}
+	return out
+}
+
+// dotenvName returns the setter name as an environment variable name, it's
+// uppercased and the characters which are not allowed are replaced with _
+// e.g. image-tag becomes IMAGE_TAG
+func dotenvName(name string) string {
+	out := dotenvUnsafeName.ReplaceAllString(strings.ToUpper(name), "_")
+	if out == "" || (out[0] >= '0' && out[0] <= '9') {
+		out = "_" + out
+	}
+	return out
+}
+
+// dotenvValue quotes the value with single quotes
+// if it contains characters special to the shell
+func dotenvValue(value string) string {
+	if dotenvSafeValue.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
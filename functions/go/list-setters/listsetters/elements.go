@@ -0,0 +1,33 @@
+package listsetters
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// elementValue returns the value of an element of an array setter field,
+// non-scalar elements e.g. the containers of a pod are reported as the value
+// of their ArrayValueField or else as their YAML with collapsed whitespace
+func (ls *ListSetters) elementValue(elem *yaml.RNode) string {
+	if elem.YNode().Kind == yaml.ScalarNode {
+		return elem.YNode().Value
+	}
+	v, err := arrayElementValue(elem, ls.ArrayValueField)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// arrayElementValue returns the value of the field of a mapping element if
+// field is not empty and the element has it as a scalar, the element
+// rendered as YAML otherwise
+func arrayElementValue(elem *yaml.RNode, field string) (string, error) {
+	if field != "" && elem.YNode().Kind == yaml.MappingNode {
+		if v := elem.Field(field); v != nil && v.Value.YNode().Kind == yaml.ScalarNode {
+			return v.Value.YNode().Value, nil
+		}
+	}
+	return elem.String()
+}
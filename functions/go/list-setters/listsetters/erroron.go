@@ -0,0 +1,159 @@
+package listsetters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+const (
+	// UnusedCategory is the category of the setters declared
+	// in the Kptfile which are not used by any resource
+	UnusedCategory = "unused"
+
+	// UndeclaredCategory is the category of the setters used by
+	// resources which are not declared in the Kptfile
+	UndeclaredCategory = "undeclared"
+
+	// ConflictCategory is the category of the scalar setters
+	// parameterizing fields with different values
+	ConflictCategory = "conflict"
+
+	// UntaggedCategory is the category of the fields without a setter
+	// comment holding the value of a setter, found if DetectUntagged is set
+	UntaggedCategory = "untagged"
+
+	// ConstraintsCategory is the category of the array setters
+	// with values which are not allowed by their Constraints
+	ConstraintsCategory = "constraints"
+
+	// MismatchCategory is the category of the fields whose value would be
+	// changed by re-applying the setters, found if Verify is set
+	MismatchCategory = "mismatch"
+)
+
+// errorCategories returns the list of the categories supported by ErrorOn
+func errorCategories() []string {
+	return []string{UnusedCategory, UndeclaredCategory, ConflictCategory, UntaggedCategory, ConstraintsCategory, MismatchCategory}
+}
+
+// parseErrorOn parses the categories of the errorOn option, either
+// a YAML list e.g. "[unused, conflict]" or comma separated
+func parseErrorOn(s string) ([]string, error) {
+	var categories []string
+	if strings.HasPrefix(strings.TrimSpace(s), "[") {
+		values, err := getArraySetterValues(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid value %q for %q: %v", s, ErrorOnKey, err)
+		}
+		categories = values
+	} else {
+		categories = strings.Split(s, ",")
+	}
+	var out []string
+	for _, c := range categories {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out, validateErrorOn(out)
+}
+
+// validateErrorOn returns an error if any of the categories is not supported
+func validateErrorOn(categories []string) error {
+	for _, c := range categories {
+		valid := false
+		for _, supported := range errorCategories() {
+			valid = valid || c == supported
+		}
+		if !valid {
+			return errors.Errorf("invalid %s category %q, must be one of %q", ErrorOnKey, c, errorCategories())
+		}
+	}
+	return nil
+}
+
+// validateErrorOnOptions returns an error if the untagged or mismatch
+// categories are set without DetectUntagged or Verify respectively, as
+// their problems are only found if the option is set
+func (ls *ListSetters) validateErrorOnOptions() error {
+	for _, c := range ls.ErrorOn {
+		switch {
+		case c == UntaggedCategory && !ls.DetectUntagged:
+			return errors.Errorf("%s category %q requires %s", ErrorOnKey, UntaggedCategory, DetectUntaggedKey)
+		case c == MismatchCategory && !ls.Verify:
+			return errors.Errorf("%s category %q requires %s", ErrorOnKey, MismatchCategory, VerifyKey)
+		}
+	}
+	return nil
+}
+
+// errorOnError returns an error listing the problems of the ErrorOn
+// categories found in the setters, the problems of each package are
+// prefixed with the package if PerPackage is set
+func (ls *ListSetters) errorOnError() error {
+	var msgs []string
+	for _, category := range ls.ErrorOn {
+		if !ls.PerPackage {
+			msgs = append(msgs, ls.categoryProblems(category)...)
+			continue
+		}
+		for _, p := range ls.Packages {
+			for _, msg := range p.Setters.categoryProblems(category) {
+				msgs = append(msgs, fmt.Sprintf("package %s: %s", p.Path, msg))
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.Errorf("found setter problems of the %s categories [%s]: %s",
+		ErrorOnKey, strings.Join(ls.ErrorOn, ", "), strings.Join(msgs, "; "))
+}
+
+// categoryProblems returns the messages of the problems of the category,
+// conflicts are the Warnings with the ConflictingValues reason, untagged
+// fields are the Untagged fields, constraints are the Violations, mismatches
+// are the Mismatches and the other problems are found in the setters listed
+// by GetResults
+func (ls *ListSetters) categoryProblems(category string) []string {
+	var out []string
+	switch category {
+	case ConflictCategory:
+		for _, w := range ls.Warnings {
+			if w.Reason == ConflictingValues {
+				out = append(out, w.Error())
+			}
+		}
+		return out
+	case UntaggedCategory:
+		for _, u := range ls.Untagged {
+			out = append(out, u.Error())
+		}
+		return out
+	case ConstraintsCategory:
+		for _, v := range ls.Violations {
+			out = append(out, v.Error())
+		}
+		return out
+	case MismatchCategory:
+		for _, m := range ls.Mismatches {
+			out = append(out, m.Error())
+		}
+		return out
+	}
+	for _, r := range ls.GetResults() {
+		switch category {
+		case UnusedCategory:
+			if ls.unused(r) {
+				out = append(out, unusedMessage(r.Name))
+			}
+		case UndeclaredCategory:
+			if ls.undeclared(r) {
+				out = append(out, undeclaredMessage(r.Name))
+			}
+		}
+	}
+	return out
+}
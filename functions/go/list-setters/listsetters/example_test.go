@@ -0,0 +1,33 @@
+package listsetters
+
+import (
+	"os"
+	"strings"
+)
+
+func ExampleListSetters_ListFrom() {
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: my-app # kpt-set: ${app}
+    spec:
+      replicas: 3 # kpt-set: ${replicas}
+functionConfig:
+  apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: list-setters-fn-config
+  data:
+    sortBy: count
+`
+	ls := New()
+	if err := ls.ListFrom(strings.NewReader(input), os.Stdout); err != nil {
+		panic(err)
+	}
+	// Output:
+	// Name: app, Value: my-app, Type: str, Count: 1
+	// Name: replicas, Value: 3, Type: int, Count: 1
+}
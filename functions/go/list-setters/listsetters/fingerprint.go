@@ -0,0 +1,38 @@
+package listsetters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// Fingerprint returns a stable hash of the name, type, value and count of
+// the setter. It only changes if one of them changes, so integrators can
+// compare it across runs to skip re-processing unchanged setters.
+func (r Result) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %q %d", r.Name, r.Type, r.Value, r.Count)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Fingerprint returns a stable hash of the fingerprints of the results,
+// which changes if any setter is added, removed or changed. It doesn't
+// depend on the order of the results e.g. the sortBy option.
+func Fingerprint(results []*Result) string {
+	var fingerprints []string
+	for _, r := range results {
+		fingerprints = append(fingerprints, r.Fingerprint())
+	}
+	sort.Strings(fingerprints)
+	h := sha256.New()
+	for _, f := range fingerprints {
+		fmt.Fprintln(h, f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Fingerprint returns the fingerprint of the results of the package
+func (ls *ListSetters) Fingerprint() string {
+	return Fingerprint(ls.GetResults())
+}
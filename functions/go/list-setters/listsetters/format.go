@@ -0,0 +1,297 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// MarshalJSON renders the value of array setters as a JSON array
+// and the value of scalar setters as a JSON string
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	out := struct {
+		result
+		Value interface{} `json:"value"`
+	}{result: result(r), Value: r.Value}
+	if r.Type == ArraySetterType {
+		values := r.Values
+		if values == nil {
+			values = []string{}
+		}
+		out.Value = values
+	}
+	return json.Marshal(out)
+}
+
+// FormatResults renders the setter results in the configured OutputFormat,
+// each returned message is reported as a separate function result item
+func (ls *ListSetters) FormatResults() ([]string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return nil, err
+	}
+	rs := ls.GetResults()
+	switch ls.Mode {
+	case CountMode:
+		return ls.formatCounts(rs)
+	case ResolveMode:
+		return ls.formatResolutions(rs)
+	}
+	if ls.SortBy == LocationSortOrder && ls.listsOccurrences() {
+		rs = occurrences(rs)
+	}
+	switch ls.OutputFormat {
+	case JSONOutputFormat:
+		if rs == nil {
+			rs = []*Result{}
+		}
+		b, err := json.Marshal(rs)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	case JSONLinesOutputFormat:
+		values := make([]interface{}, len(rs))
+		for i := range rs {
+			values[i] = rs[i]
+		}
+		return jsonLines(values)
+	case MarkdownOutputFormat:
+		return []string{markdownTable(ls.shownResults(rs))}, nil
+	case HistogramOutputFormat:
+		return ls.formatHistogram(), nil
+	case DotenvOutputFormat:
+		return ls.dotenvLines(rs), nil
+	case SettersConfigOutputFormat:
+		data, err := settersConfigData(rs, ls.syntax())
+		if err != nil {
+			return nil, err
+		}
+		return []string{data}, nil
+	case SARIFOutputFormat:
+		log, err := ls.formatSARIF(rs)
+		if err != nil {
+			return nil, err
+		}
+		return []string{log}, nil
+	case OnelineOutputFormat:
+		return []string{ls.formatOneline()}, nil
+	default:
+		var out []string
+		for _, r := range ls.shownResults(rs) {
+			out = append(out, r.String())
+		}
+		return out, nil
+	}
+}
+
+// shownResults returns copies of the results whose array setter values are
+// truncated to the first MaxValuesShown values followed by the number of the
+// others, the counts of the setters are kept
+func (ls *ListSetters) shownResults(rs []*Result) []*Result {
+	if ls.MaxValuesShown <= 0 {
+		return rs
+	}
+	out := make([]*Result, len(rs))
+	for i, r := range rs {
+		out[i] = r
+		if r.Type != ArraySetterType || len(r.Values) <= ls.MaxValuesShown {
+			continue
+		}
+		shown := *r
+		shown.Value = fmt.Sprintf("[%s, ... (+%d more)]", strings.Join(r.Values[:ls.MaxValuesShown], ", "), len(r.Values)-ls.MaxValuesShown)
+		out[i] = &shown
+	}
+	return out
+}
+
+// listsOccurrences returns true if the OutputFormat lists the results one by
+// one with their locations, so that they can be split into occurrences for the
+// LocationSortOrder. The other formats aggregate the setters.
+func (ls *ListSetters) listsOccurrences() bool {
+	switch ls.OutputFormat {
+	case TextOutputFormat, JSONOutputFormat, JSONLinesOutputFormat:
+		return true
+	}
+	return false
+}
+
+// jsonLines renders each of the values as a single line JSON object,
+// so that consumers can process the results one line at a time
+func jsonLines(values []interface{}) ([]string, error) {
+	out := make([]string, len(values))
+	for i, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		out[i] = string(b)
+	}
+	return out, nil
+}
+
+// markdownTable renders the results as a GitHub flavored Markdown table
+func markdownTable(rs []*Result) string {
+	lines := []string{"| Name | Type | Value | Count |", "| --- | --- | --- | --- |"}
+	for _, r := range rs {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %d |",
+			escapeMarkdownCell(r.Name), escapeMarkdownCell(r.Type), escapeMarkdownCell(r.Value), r.Count))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatHistogram renders each bucket of the UsageHistogram as a line
+func (ls *ListSetters) formatHistogram() []string {
+	var out []string
+	for _, b := range ls.UsageHistogram() {
+		out = append(out, b.String())
+	}
+	return out
+}
+
+// escapeMarkdownCell escapes the characters which would break a Markdown table cell
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// FormatPackageResults renders the setter results of each package in the
+// configured OutputFormat, the text format starts each package section
+// with a line naming the package, the markdown format renders a table
+// under a heading naming each package and the setters-config format renders
+// a data block after a comment naming each package
+func (ls *ListSetters) FormatPackageResults() ([]string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return nil, err
+	}
+	prs := ls.GetPackageResults()
+	if ls.SortBy == LocationSortOrder && ls.listsOccurrences() {
+		for _, pr := range prs {
+			pr.Setters = occurrences(pr.Setters)
+		}
+	}
+	switch ls.OutputFormat {
+	case JSONOutputFormat:
+		if prs == nil {
+			prs = []*PackageResult{}
+		}
+		for _, pr := range prs {
+			if pr.Setters == nil {
+				pr.Setters = []*Result{}
+			}
+		}
+		b, err := json.Marshal(prs)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	case JSONLinesOutputFormat:
+		values := make([]interface{}, len(prs))
+		for i, pr := range prs {
+			if pr.Setters == nil {
+				pr.Setters = []*Result{}
+			}
+			values[i] = pr
+		}
+		return jsonLines(values)
+	case HistogramOutputFormat:
+		return ls.formatHistogram(), nil
+	case OnelineOutputFormat:
+		return []string{ls.formatOneline()}, nil
+	case DotenvOutputFormat:
+		var rs []*Result
+		for _, pr := range prs {
+			rs = append(rs, pr.Setters...)
+		}
+		return ls.dotenvLines(rs), nil
+	case MarkdownOutputFormat:
+		var out []string
+		for _, pr := range prs {
+			out = append(out, fmt.Sprintf("#### Package: %s\n\n%s", pr.Package, markdownTable(ls.shownResults(pr.Setters))))
+		}
+		return out, nil
+	case SettersConfigOutputFormat:
+		var out []string
+		for _, pr := range prs {
+			data, err := settersConfigData(pr.Setters, ls.syntax())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, fmt.Sprintf("# Package: %s\n%s", pr.Package, data))
+		}
+		return out, nil
+	default:
+		var out []string
+		for _, pr := range prs {
+			out = append(out, fmt.Sprintf("Package: %s", pr.Package))
+			for _, r := range ls.shownResults(pr.Setters) {
+				out = append(out, r.String())
+			}
+		}
+		return out, nil
+	}
+}
+
+// FormatChanges renders the dry-run changes in the configured OutputFormat,
+// each returned message is reported as a separate function result item
+func (ls *ListSetters) FormatChanges() ([]string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return nil, err
+	}
+	switch ls.OutputFormat {
+	case JSONOutputFormat:
+		changes := ls.Changes
+		if changes == nil {
+			changes = []Change{}
+		}
+		b, err := json.Marshal(changes)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	case JSONLinesOutputFormat:
+		values := make([]interface{}, len(ls.Changes))
+		for i := range ls.Changes {
+			values[i] = ls.Changes[i]
+		}
+		return jsonLines(values)
+	default:
+		var out []string
+		for _, c := range ls.Changes {
+			out = append(out, c.String())
+		}
+		return out, nil
+	}
+}
+
+// FormatSummary renders the Summary of the setters in the configured OutputFormat
+func (ls *ListSetters) FormatSummary() (string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return "", err
+	}
+	s := ls.Summarize()
+	if ls.OutputFormat == JSONOutputFormat || ls.OutputFormat == JSONLinesOutputFormat {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		return string(b), nil
+	}
+	return s.String(), nil
+}
+
+// FormatStats renders the Stats of the scanned documents in the configured OutputFormat
+func (ls *ListSetters) FormatStats() (string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return "", err
+	}
+	if ls.OutputFormat == JSONOutputFormat || ls.OutputFormat == JSONLinesOutputFormat {
+		b, err := json.Marshal(ls.Stats)
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		return string(b), nil
+	}
+	return ls.Stats.String(), nil
+}
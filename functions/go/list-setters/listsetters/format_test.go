@@ -0,0 +1,550 @@
+package listsetters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestFormatResults(t *testing.T) {
+	var tests = []struct {
+		name           string
+		format         string
+		scalarSetters  map[string]*ScalarSetter
+		arraySetters   map[string]*ArraySetter
+		arraySeparator string
+		maxValuesShown int
+		expected       []string
+		errMsg         string
+	}{
+		{
+			name:   "text",
+			format: TextOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app": {Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2,
+					Inconsistencies: []Inconsistency{{File: "a.yaml", Resource: "Service/a", Fields: []FieldValue{{Path: "metadata.name", Value: "my-app"}, {Path: "spec.selector.app", Value: "web"}}}}},
+				"replicas": {Name: "replicas", Value: "3", Type: "int", ValueType: "int", Count: 1, Overridden: true},
+				"tag":      {Name: "tag", Value: "1.0", Type: "str", ValueType: "float", Count: 1, Default: "latest", Source: "pipeline.mutators[0]"},
+				"image":    {Name: "image", Value: "nginx:${tag}", Type: "str", ValueType: "string", Count: 1},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{
+				"Name: app, Value: my-app, Type: str, Count: 2, Inconsistent: true",
+				"Name: image, Value: nginx:${tag}, Type: str, Count: 1, Depends on: [tag]",
+				"Name: images, Value: [hbase, ubuntu], Type: array, Count: 1",
+				"Name: replicas, Value: 3, Type: int, Count: 1, Overridden: true",
+				"Name: tag, Value: 1.0, Type: str, Count: 1, Default: latest, Source: pipeline.mutators[0]",
+			},
+		},
+		{
+			name:   "json",
+			format: JSONOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app": {Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2, Files: map[string]int{"b.yaml": 1, "a.yaml": 1},
+					Resources: map[string]int{"v1/Service//a": 1, "v1/Service//b": 1}},
+				"replicas": {Name: "replicas", Value: "3", Type: "int", ValueType: "int", Count: 1, Source: "pipeline.mutators[0]"},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+				"empty":  {Name: "empty", Count: 0},
+			},
+			expected: []string{`[{"name":"app","value":"my-app","type":"str","valueType":"string","count":2,"fieldCount":2,"resourceCount":2,"files":["a.yaml","b.yaml"]},` +
+				`{"name":"empty","value":[],"type":"array","count":0,"fieldCount":0,"resourceCount":0},` +
+				`{"name":"images","value":["hbase","ubuntu"],"type":"array","count":1,"fieldCount":1,"resourceCount":0},` +
+				`{"name":"replicas","value":"3","type":"int","valueType":"int","count":1,"fieldCount":1,"resourceCount":0,"source":"pipeline.mutators[0]"}]`},
+		},
+		{
+			name:   "json lines",
+			format: JSONLinesOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"replicas": {Name: "replicas", Value: "3", Type: "int", ValueType: "int", Count: 1},
+				"app":      {Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 1, Files: map[string]int{"a.yaml": 1}},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{
+				`{"name":"app","value":"my-app","type":"str","valueType":"string","count":1,"fieldCount":1,"resourceCount":0,"files":["a.yaml"]}`,
+				`{"name":"images","value":["hbase","ubuntu"],"type":"array","count":1,"fieldCount":1,"resourceCount":0}`,
+				`{"name":"replicas","value":"3","type":"int","valueType":"int","count":1,"fieldCount":1,"resourceCount":0}`,
+			},
+		},
+		{
+			name:   "text max values shown",
+			format: TextOutputFormat,
+			arraySetters: map[string]*ArraySetter{
+				"zones": {Name: "zones", Values: []string{"a", "b", "c", "d", "e"}, Count: 2},
+				"envs":  {Name: "envs", Values: []string{"dev", "prod"}, Count: 1},
+			},
+			maxValuesShown: 2,
+			expected: []string{
+				"Name: envs, Value: [dev, prod], Type: array, Count: 1",
+				"Name: zones, Value: [a, b, ... (+3 more)], Type: array, Count: 2",
+			},
+		},
+		{
+			name:   "json max values shown",
+			format: JSONOutputFormat,
+			arraySetters: map[string]*ArraySetter{
+				"zones": {Name: "zones", Values: []string{"a", "b", "c"}, Count: 1},
+			},
+			maxValuesShown: 1,
+			expected:       []string{`[{"name":"zones","value":["a","b","c"],"type":"array","count":1,"fieldCount":1,"resourceCount":0}]`},
+		},
+		{
+			name:     "json lines no setters",
+			format:   JSONLinesOutputFormat,
+			expected: []string{},
+		},
+		{
+			name:   "markdown",
+			format: MarkdownOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app":   {Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2},
+				"regex": {Name: "regex", Value: "a|b", Type: "str", ValueType: "string", Count: 1},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{"| Name | Type | Value | Count |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| app | str | my-app | 2 |\n" +
+				"| images | array | [hbase, ubuntu] | 1 |\n" +
+				`| regex | str | a\|b | 1 |`},
+		},
+		{
+			name:     "markdown no setters",
+			format:   MarkdownOutputFormat,
+			expected: []string{"| Name | Type | Value | Count |\n| --- | --- | --- | --- |"},
+		},
+		{
+			name:     "json no setters",
+			format:   JSONOutputFormat,
+			expected: []string{`[]`},
+		},
+		{
+			name:   "histogram",
+			format: HistogramOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app":      {Name: "app", Value: "my-app", Type: "str", Count: 2},
+				"env":      {Name: "env", Value: "dev", Type: "str", Count: 7},
+				"replicas": {Name: "replicas", Value: "3", Type: "int", Count: 1},
+				"tag":      {Name: "tag", Value: "1.0", Type: "str", Count: 3},
+				"unused":   {Name: "unused", Value: "foo", Type: "str", Count: 0},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 5},
+				"zones":  {Name: "zones", Values: []string{"a"}, Count: 6},
+			},
+			expected: []string{
+				"Count: 0, Setters: 1",
+				"Count: 1, Setters: 1",
+				"Count: 2, Setters: 1",
+				"Count: 3-5, Setters: 2",
+				"Count: 6+, Setters: 2",
+			},
+		},
+		{
+			name:   "dotenv",
+			format: DotenvOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app":         {Name: "app", Value: "my-app", Type: "str", Count: 2},
+				"image-tag":   {Name: "image-tag", Value: "1.16.1", Type: "str", Count: 1},
+				"description": {Name: "description", Value: "it's an app", Type: "str", Count: 1},
+				"1st.zone":    {Name: "1st.zone", Value: "us-east1", Type: "str", Count: 1},
+				"unresolved":  {Name: "unresolved", Value: "${project}-bucket", Type: "str", Count: 0},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{
+				"_1ST_ZONE=us-east1",
+				"APP=my-app",
+				`DESCRIPTION='it'\''s an app'`,
+				"IMAGE_TAG=1.16.1",
+				"IMAGES=hbase,ubuntu",
+			},
+		},
+		{
+			name:   "dotenv space separated arrays",
+			format: DotenvOutputFormat,
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			arraySeparator: " ",
+			expected:       []string{"IMAGES='hbase ubuntu'"},
+		},
+		{
+			name:   "setters config",
+			format: SettersConfigOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app":        {Name: "app", Value: "my-app", Type: "str", Count: 2},
+				"replicas":   {Name: "replicas", Value: "3", Type: "int", Count: 1},
+				"unresolved": {Name: "unresolved", Value: "${project}-bucket", Type: "str", Count: 0},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{"data:\n" +
+				"  app: my-app\n" +
+				"  images: |\n" +
+				"    - hbase\n" +
+				"    - ubuntu\n" +
+				"  replicas: \"3\""},
+		},
+		{
+			name:   "oneline",
+			format: OnelineOutputFormat,
+			scalarSetters: map[string]*ScalarSetter{
+				"app":      {Name: "app", Value: "my-app", Type: "str", Count: 2},
+				"replicas": {Name: "replicas", Value: "3", Type: "int", Count: 1},
+				"unused":   {Name: "unused", Value: "foo", Type: "str", Count: 0},
+			},
+			arraySetters: map[string]*ArraySetter{
+				"images": {Name: "images", Values: []string{"hbase", "ubuntu"}, Count: 1},
+			},
+			expected: []string{"4 setters (3 scalar, 1 array), 1 unused, 0 undeclared"},
+		},
+		{
+			name:     "oneline no setters",
+			format:   OnelineOutputFormat,
+			expected: []string{"0 setters (0 scalar, 0 array), 0 unused, 0 undeclared"},
+		},
+		{
+			name:   "invalid format",
+			format: "xml",
+			errMsg: `invalid output format "xml", must be one of ["text" "json" "markdown" "histogram" "dotenv" "setters-config" "sarif" "jsonl" "oneline"]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.OutputFormat = test.format
+			if test.arraySeparator != "" {
+				ls.ArraySeparator = test.arraySeparator
+			}
+			ls.MaxValuesShown = test.maxValuesShown
+			for k, v := range test.scalarSetters {
+				ls.ScalarSetters[k] = v
+			}
+			for k, v := range test.arraySetters {
+				ls.ArraySetters[k] = v
+			}
+			actual, err := ls.FormatResults()
+			if test.errMsg != "" {
+				require.EqualError(t, err, test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			if test.format == JSONOutputFormat {
+				require.Len(t, actual, 1)
+				require.JSONEq(t, test.expected[0], actual[0])
+				return
+			}
+			if test.format == JSONLinesOutputFormat {
+				require.Len(t, actual, len(test.expected))
+				for i := range test.expected {
+					require.JSONEq(t, test.expected[i], actual[i])
+				}
+				return
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFormatResultsSortByLocation(t *testing.T) {
+	var nodes []*yaml.RNode
+	for file, content := range map[string]string{"b.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+`, "a.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: my-app # kpt-set: ${app}
+`} {
+		node, err := yaml.Parse(content)
+		require.NoError(t, err)
+		require.NoError(t, node.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, file)))
+		nodes = append(nodes, node)
+	}
+	ls := New()
+	ls.SortBy = LocationSortOrder
+	_, err := ls.Filter(nodes)
+	require.NoError(t, err)
+	actual, err := ls.FormatResults()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Name: app, Value: my-app, Type: str, Count: 1, Locations: [a.yaml:6:10 metadata.labels.app]",
+		"Name: app, Value: my-app, Type: str, Count: 1, Locations: [a.yaml:4:9 metadata.name]",
+		"Name: app, Value: my-app, Type: str, Count: 1, Locations: [b.yaml:4:9 metadata.name]",
+		"Name: replicas, Value: 3, Type: int, Count: 1, Locations: [b.yaml:6:13 spec.replicas]",
+	}, actual)
+
+	ls.OutputFormat = DotenvOutputFormat
+	actual, err = ls.FormatResults()
+	require.NoError(t, err)
+	require.Equal(t, []string{"APP=my-app", "REPLICAS=3"}, actual)
+}
+
+func TestFormatChanges(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		changes  []Change
+		expected []string
+	}{
+		{
+			name:     "text",
+			format:   TextOutputFormat,
+			changes:  []Change{{File: "a.yaml", Path: "spec.replicas", Old: "3", New: "4"}},
+			expected: []string{"File: a.yaml, Path: spec.replicas, Old: 3, New: 4"},
+		},
+		{
+			name:     "json",
+			format:   JSONOutputFormat,
+			changes:  []Change{{File: "a.yaml", Path: "spec.replicas", Old: "3", New: "4"}},
+			expected: []string{`[{"file":"a.yaml","path":"spec.replicas","old":"3","new":"4"}]`},
+		},
+		{
+			name:     "json no changes",
+			format:   JSONOutputFormat,
+			expected: []string{`[]`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.OutputFormat = test.format
+			ls.Changes = test.changes
+			actual, err := ls.FormatChanges()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "text",
+			format:   TextOutputFormat,
+			expected: "Summary: Setters: 3, Scalar: 2, Array: 1, Occurrences: 4",
+		},
+		{
+			name:     "json",
+			format:   JSONOutputFormat,
+			expected: `{"setters":3,"scalarSetters":2,"arraySetters":1,"occurrences":4}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.OutputFormat = test.format
+			ls.ScalarSetters["app"] = &ScalarSetter{Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2}
+			ls.ScalarSetters["unused"] = &ScalarSetter{Name: "unused", Value: "foo", Type: "str", ValueType: "string"}
+			ls.ArraySetters["images"] = &ArraySetter{Name: "images", Values: []string{"ubuntu"}, Count: 2}
+			actual, err := ls.FormatSummary()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "text",
+			format:   TextOutputFormat,
+			expected: "Stats: Documents: 3, Scanned: 2, Skipped: 1, Truncated: 1, Kptfile found: true",
+		},
+		{
+			name:     "json",
+			format:   JSONOutputFormat,
+			expected: `{"documents":3,"scanned":2,"skipped":1,"truncated":1,"kptfileFound":true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.OutputFormat = test.format
+			ls.Stats = Stats{Documents: 3, Scanned: 2, Skipped: 1, Truncated: 1, KptfileFound: true}
+			actual, err := ls.FormatStats()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFormatPackageResults(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		expected []string
+	}{
+		{
+			name:   "text",
+			format: TextOutputFormat,
+			expected: []string{
+				"Package: .",
+				"Name: app, Value: my-app, Type: str, Count: 1",
+				"Package: sub",
+			},
+		},
+		{
+			name:   "json",
+			format: JSONOutputFormat,
+			expected: []string{`[{"package":".","setters":[{"name":"app","value":"my-app","type":"str","valueType":"string","count":1,"fieldCount":1,"resourceCount":0}]},` +
+				`{"package":"sub","setters":[]}]`},
+		},
+		{
+			name:     "oneline",
+			format:   OnelineOutputFormat,
+			expected: []string{"1 setters (1 scalar, 0 array), 0 unused, 1 undeclared"},
+		},
+		{
+			name:   "markdown",
+			format: MarkdownOutputFormat,
+			expected: []string{
+				"#### Package: .\n\n| Name | Type | Value | Count |\n| --- | --- | --- | --- |\n| app | str | my-app | 1 |",
+				"#### Package: sub\n\n| Name | Type | Value | Count |\n| --- | --- | --- | --- |",
+			},
+		},
+		{
+			name:   "setters-config",
+			format: SettersConfigOutputFormat,
+			expected: []string{
+				"# Package: .\ndata:\n  app: my-app",
+				"# Package: sub\ndata: {}",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.OutputFormat = test.format
+			root := New()
+			root.ScalarSetters["app"] = &ScalarSetter{Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 1}
+			root.kfSetters = map[string]string{"tag": "1.0"}
+			sub := New()
+			ls.Packages = []*PackageSetters{{Path: ".", Setters: &root}, {Path: "sub", Setters: &sub}}
+			actual, err := ls.FormatPackageResults()
+			require.NoError(t, err)
+			if test.format == JSONOutputFormat {
+				require.Len(t, actual, 1)
+				require.JSONEq(t, test.expected[0], actual[0])
+				return
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestSettersConfigDataRoundTrip(t *testing.T) {
+	rs := []*Result{
+		{Name: "images", Type: ArraySetterType, Values: []string{"nginx:1.16", "true", "a b"}},
+		{Name: "empty", Type: ArraySetterType},
+		{Name: "motd", Type: "str", Value: "hello\nworld"},
+	}
+	data, err := settersConfigData(rs, DollarBraceSyntax)
+	require.NoError(t, err)
+	var cm struct {
+		Data map[string]string `yaml:"data"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(data), &cm))
+	for _, r := range rs {
+		if r.Type != ArraySetterType {
+			require.Equal(t, r.Value, cm.Data[r.Name])
+			continue
+		}
+		values, err := getArraySetterValues(cm.Data[r.Name])
+		require.NoError(t, err)
+		require.Equal(t, len(r.Values), len(values))
+		for i := range values {
+			require.Equal(t, r.Values[i], values[i])
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []*Result{
+		{Name: "app", Value: "my-app", Type: "str", Count: 2},
+		{Name: "replicas", Value: "3", Type: "int", Count: 1},
+		{Name: "tag", Value: "1.0", Type: "str", Count: 2},
+		{Name: "zones", Value: "[a]", Values: []string{"a"}, Type: ArraySetterType, Count: 1},
+	}
+	new := []*Result{
+		{Name: "app", Value: "my-app", Type: "str", Count: 2},
+		{Name: "env", Value: "prod", Type: "str", Count: 1},
+		{Name: "tag", Value: "1.1", Type: "str", Count: 3},
+		{Name: "zones", Value: "[a, b]", Values: []string{"a", "b"}, Type: ArraySetterType, Count: 1},
+	}
+	d := Diff(old, new)
+	require.Equal(t, InventoryDiff{
+		Added:   []*Result{new[1]},
+		Removed: []*Result{old[1]},
+		Changed: []SetterChange{
+			{Name: "tag", OldValue: "1.0", NewValue: "1.1", OldType: "str", NewType: "str", OldCount: 2, NewCount: 3, CountDelta: 1},
+			{Name: "zones", OldValue: "[a]", NewValue: "[a, b]", OldType: "array", NewType: "array", OldCount: 1, NewCount: 1},
+		},
+	}, d)
+	require.True(t, Diff(old, old).Empty())
+
+	ls := New()
+	actual, err := ls.FormatDiff(d)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Added: Name: env, Value: prod, Type: str, Count: 1",
+		"Removed: Name: replicas, Value: 3, Type: int, Count: 1",
+		"Changed: Name: tag, Value: 1.0 -> 1.1, Count: 2 -> 3 (+1)",
+		"Changed: Name: zones, Value: [a] -> [a, b]",
+	}, actual)
+
+	ls.OutputFormat = MarkdownOutputFormat
+	actual, err = ls.FormatDiff(d)
+	require.NoError(t, err)
+	require.Equal(t, []string{"| Change | Name | Old Value | New Value | Count |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| added | env |  | prod | 1 |\n" +
+		"| removed | replicas | 3 |  | 1 |\n" +
+		"| changed | tag | 1.0 | 1.1 | 3 (+1) |\n" +
+		"| changed | zones | [a] | [a, b] | 1 (+0) |"}, actual)
+
+	ls.OutputFormat = DotenvOutputFormat
+	_, err = ls.FormatDiff(d)
+	require.EqualError(t, err, `format "dotenv" doesn't support inventory diffs, must be one of ["text" "json" "markdown"]`)
+}
+
+func TestReadResults(t *testing.T) {
+	ls := New()
+	ls.OutputFormat = JSONOutputFormat
+	ls.ScalarSetters["app"] = &ScalarSetter{Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2, Files: map[string]int{"a.yaml": 2}}
+	ls.ArraySetters["zones"] = &ArraySetter{Name: "zones", Values: []string{"a", "b"}, Count: 1}
+	rendered, err := ls.FormatResults()
+	require.NoError(t, err)
+
+	actual, err := ReadResults(strings.NewReader(rendered[0]))
+	require.NoError(t, err)
+	require.Equal(t, ls.GetResults(), actual)
+
+	_, err = ReadResults(strings.NewReader(`{"name": "app"}`))
+	require.EqualError(t, err, "unable to read results: json: cannot unmarshal object into Go value of type []*listsetters.Result")
+}
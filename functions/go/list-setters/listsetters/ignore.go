@@ -0,0 +1,80 @@
+package listsetters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// parseIgnoreAnnotations parses the comma separated key=value annotations of
+// the ignoreAnnotations option, a key without value matches any of its values
+func parseIgnoreAnnotations(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, value := entry, ""
+		if i := strings.Index(entry, "="); i >= 0 {
+			key, value = strings.TrimSpace(entry[:i]), strings.Trim(strings.TrimSpace(entry[i+1:]), `"'`)
+		}
+		if key == "" {
+			return nil, errors.Errorf("invalid %s entry %q, the annotation key must not be empty", IgnoreAnnotationsKey, entry)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// ignoredAnnotation returns the first annotation of the node in key order
+// matching the IgnoreAnnotations as key=value, empty if none matches
+func (ls *ListSetters) ignoredAnnotation(node *yaml.RNode) string {
+	if len(ls.IgnoreAnnotations) == 0 {
+		return ""
+	}
+	var keys []string
+	for k := range ls.IgnoreAnnotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	annotations := node.GetAnnotations()
+	for _, k := range keys {
+		v, ok := annotations[k]
+		if ok && (ls.IgnoreAnnotations[k] == "" || ls.IgnoreAnnotations[k] == v) {
+			return fmt.Sprintf("%s=%s", k, v)
+		}
+	}
+	return ""
+}
+
+// IgnoreDirective follows the setter pattern in a setter comment to exclude
+// the field from the listed setters e.g. `# kpt-set: ${foo} # list-setters:ignore`
+const IgnoreDirective = "list-setters:ignore"
+
+// ignoreDirective matches the IgnoreDirective as a comment of its own
+var ignoreDirective = regexp.MustCompile(`(^|\s)#\s*` + regexp.QuoteMeta(IgnoreDirective) + `(\s|$)`)
+
+// ignoredSetterComment returns true if the comment is a setter
+// comment whose setter pattern is followed by the IgnoreDirective
+func ignoredSetterComment(comment, identifier string) bool {
+	rest, ok := trimSetterComment(comment, identifier)
+	return ok && ignoreDirective.MatchString(rest)
+}
+
+// hasIgnoredSetterComment returns true if the line comment of the node,
+// or one of the lines of its head comment, is an ignored setter comment
+func hasIgnoredSetterComment(n *yaml.Node, identifier string) bool {
+	if ignoredSetterComment(n.LineComment, identifier) {
+		return true
+	}
+	for _, line := range strings.Split(n.HeadComment, "\n") {
+		if ignoredSetterComment(strings.TrimSpace(line), identifier) {
+			return true
+		}
+	}
+	return false
+}
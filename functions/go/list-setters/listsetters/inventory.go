@@ -0,0 +1,161 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"path"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ConfigMapInventoryKind renders the inventory as a ConfigMap
+	// mapping the setter names to their values
+	ConfigMapInventoryKind = "ConfigMap"
+
+	// SetterInventoryKind renders the inventory as a SetterInventory
+	// resource listing the results of the setters
+	SetterInventoryKind = "SetterInventory"
+
+	// SetterInventoryAPIVersion is the apiVersion of SetterInventory resources
+	SetterInventoryAPIVersion = "fn.kpt.dev/v1alpha1"
+
+	// inventoryName is the name of the inventory resource
+	inventoryName = "setter-inventory"
+)
+
+// inventoryKinds returns the supported kinds of the inventory resource
+func inventoryKinds() []string {
+	return []string{ConfigMapInventoryKind, SetterInventoryKind}
+}
+
+// validateInventoryKind validates the configured kind of the inventory resource
+func (ls *ListSetters) validateInventoryKind() error {
+	if ls.InventoryKind == "" {
+		return nil
+	}
+	var ok bool
+	for _, k := range inventoryKinds() {
+		ok = ok || ls.InventoryKind == k
+	}
+	if !ok {
+		return errors.Errorf("invalid %s %q, must be one of %q", InventoryKindKey, ls.InventoryKind, inventoryKinds())
+	}
+	if ls.PerPackage && ls.InventoryKind == ConfigMapInventoryKind {
+		return errors.Errorf("%s %q can't hold the setters of each package, use %q with %s",
+			InventoryKindKey, ConfigMapInventoryKind, SetterInventoryKind, PerPackageKey)
+	}
+	return nil
+}
+
+// withoutInventory returns the nodes without the inventory resource
+// generated by a previous run, which is replaced rather than scanned. An
+// error is returned if other resources are at the InventoryPath, as they
+// would be overwritten by the inventory.
+func (ls *ListSetters) withoutInventory(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if ls.InventoryPath == "" {
+		return nodes, nil
+	}
+	var out []*yaml.RNode
+	for _, node := range nodes {
+		if path.Clean(nodePath(node)) != path.Clean(ls.InventoryPath) {
+			out = append(out, node)
+			continue
+		}
+		if !isInventory(node) {
+			return nodes, errors.Errorf("%s %q holds the resource %s/%s which is not a setter inventory",
+				InventoryPathKey, ls.InventoryPath, node.GetKind(), node.GetName())
+		}
+	}
+	return out, nil
+}
+
+// isInventory returns true if the node is an inventory resource
+func isInventory(node *yaml.RNode) bool {
+	if node.GetName() != inventoryName {
+		return false
+	}
+	for _, k := range inventoryKinds() {
+		if node.GetKind() == k {
+			return true
+		}
+	}
+	return false
+}
+
+// appendInventory appends the inventory resource of the discovered setters
+// at the InventoryPath to the nodes, if InventoryPath is set
+func (ls *ListSetters) appendInventory(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if ls.InventoryPath == "" {
+		return nodes, nil
+	}
+	inventory, err := ls.inventory()
+	if err != nil {
+		return nodes, err
+	}
+	return append(nodes, inventory), nil
+}
+
+// inventory returns the inventory resource of the discovered setters, a local
+// config resource so that it's materialized in the package but not deployed
+func (ls *ListSetters) inventory() (*yaml.RNode, error) {
+	kind := ls.InventoryKind
+	if kind == "" {
+		kind = ConfigMapInventoryKind
+	}
+	apiVersion := "v1"
+	if kind == SetterInventoryKind {
+		apiVersion = SetterInventoryAPIVersion
+	}
+	node := yaml.NewMapRNode(nil)
+	if err := node.PipeE(yaml.SetField("apiVersion", yaml.NewStringRNode(apiVersion))); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if err := node.PipeE(yaml.SetField("kind", yaml.NewStringRNode(kind))); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if err := node.SetName(inventoryName); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	annotations := map[string]string{
+		"config.kubernetes.io/local-config": "true",
+		kioutil.PathAnnotation:              path.Clean(ls.InventoryPath),
+		kioutil.IndexAnnotation:             "0",
+	}
+	for k, v := range annotations {
+		if err := node.PipeE(yaml.SetAnnotation(k, v)); err != nil {
+			return nil, errors.Wrap(err)
+		}
+	}
+
+	if kind == ConfigMapInventoryKind {
+		data := make(map[string]string)
+		for _, r := range ls.GetResults() {
+			data[r.Name] = r.Value
+		}
+		node.SetDataMap(data)
+		return node, nil
+	}
+
+	var spec interface{} = struct {
+		Setters []*Result `json:"setters"`
+	}{Setters: ls.GetResults()}
+	if ls.PerPackage {
+		spec = struct {
+			Packages []*PackageResult `json:"packages"`
+		}{Packages: ls.GetPackageResults()}
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	specNode, err := yaml.ConvertJSONToYamlNode(string(b))
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if err := node.PipeE(yaml.SetField("spec", specNode)); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return node, nil
+}
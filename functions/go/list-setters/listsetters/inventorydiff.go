@@ -0,0 +1,176 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// InventoryDiff is the difference between two inventories of setters,
+// e.g. the setters of the base and head renders of a package
+type InventoryDiff struct {
+	// Added are the setters only listed in the new inventory
+	Added []*Result `json:"added"`
+
+	// Removed are the setters only listed in the old inventory
+	Removed []*Result `json:"removed"`
+
+	// Changed are the setters listed in both inventories
+	// with a different value, type or count
+	Changed []SetterChange `json:"changed"`
+}
+
+// SetterChange is the change of a setter listed in both inventories
+type SetterChange struct {
+	Name     string `json:"name"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+	OldType  string `json:"oldType"`
+	NewType  string `json:"newType"`
+	OldCount int    `json:"oldCount"`
+	NewCount int    `json:"newCount"`
+
+	// CountDelta is the difference of NewCount and OldCount
+	CountDelta int `json:"countDelta"`
+}
+
+func (c SetterChange) String() string {
+	s := fmt.Sprintf("Name: %s", c.Name)
+	if c.OldValue != c.NewValue {
+		s += fmt.Sprintf(", Value: %s -> %s", c.OldValue, c.NewValue)
+	}
+	if c.OldType != c.NewType {
+		s += fmt.Sprintf(", Type: %s -> %s", c.OldType, c.NewType)
+	}
+	if c.CountDelta != 0 {
+		s += fmt.Sprintf(", Count: %d -> %d (%+d)", c.OldCount, c.NewCount, c.CountDelta)
+	}
+	return s
+}
+
+// Diff returns the setters added, removed and changed between the old and
+// the new results, setters are matched by name and sorted by name
+func Diff(old, new []*Result) InventoryDiff {
+	d := InventoryDiff{Added: []*Result{}, Removed: []*Result{}, Changed: []SetterChange{}}
+	oldByName := make(map[string]*Result)
+	for _, r := range old {
+		oldByName[r.Name] = r
+	}
+	newByName := make(map[string]*Result)
+	for _, r := range new {
+		newByName[r.Name] = r
+	}
+	for name, n := range newByName {
+		o, ok := oldByName[name]
+		if !ok {
+			d.Added = append(d.Added, n)
+			continue
+		}
+		if o.Value != n.Value || o.Type != n.Type || o.Count != n.Count {
+			d.Changed = append(d.Changed, SetterChange{Name: name, OldValue: o.Value, NewValue: n.Value,
+				OldType: o.Type, NewType: n.Type, OldCount: o.Count, NewCount: n.Count, CountDelta: n.Count - o.Count})
+		}
+	}
+	for name, o := range oldByName {
+		if _, ok := newByName[name]; !ok {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].Name < d.Added[j].Name })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].Name < d.Removed[j].Name })
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Name < d.Changed[j].Name })
+	return d
+}
+
+// Empty returns true if the inventories have the same setters
+func (d InventoryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// FormatDiff renders the InventoryDiff in the configured OutputFormat, one
+// of the text, json or markdown formats, each returned message is reported
+// as a separate function result item
+func (ls *ListSetters) FormatDiff(d InventoryDiff) ([]string, error) {
+	if err := ls.validateOutputFormat(); err != nil {
+		return nil, err
+	}
+	switch ls.OutputFormat {
+	case TextOutputFormat:
+		var out []string
+		for _, r := range d.Added {
+			out = append(out, "Added: "+r.String())
+		}
+		for _, r := range d.Removed {
+			out = append(out, "Removed: "+r.String())
+		}
+		for _, c := range d.Changed {
+			out = append(out, "Changed: "+c.String())
+		}
+		return out, nil
+	case JSONOutputFormat:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	case MarkdownOutputFormat:
+		return []string{markdownDiff(d)}, nil
+	}
+	return nil, errors.Errorf("%s %q doesn't support inventory diffs, must be one of %q",
+		OutputFormatKey, ls.OutputFormat, []string{TextOutputFormat, JSONOutputFormat, MarkdownOutputFormat})
+}
+
+// markdownDiff renders the InventoryDiff as a GitHub flavored Markdown table,
+// e.g. to be posted as a pull request comment
+func markdownDiff(d InventoryDiff) string {
+	lines := []string{"| Change | Name | Old Value | New Value | Count |", "| --- | --- | --- | --- | --- |"}
+	for _, r := range d.Added {
+		lines = append(lines, fmt.Sprintf("| added | %s |  | %s | %d |", escapeMarkdownCell(r.Name), escapeMarkdownCell(r.Value), r.Count))
+	}
+	for _, r := range d.Removed {
+		lines = append(lines, fmt.Sprintf("| removed | %s | %s |  | %d |", escapeMarkdownCell(r.Name), escapeMarkdownCell(r.Value), r.Count))
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, fmt.Sprintf("| changed | %s | %s | %s | %d (%+d) |",
+			escapeMarkdownCell(c.Name), escapeMarkdownCell(c.OldValue), escapeMarkdownCell(c.NewValue), c.NewCount, c.CountDelta))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// UnmarshalJSON reads the results rendered by MarshalJSON, the value
+// of array setters is read from a JSON array into Values
+func (r *Result) UnmarshalJSON(b []byte) error {
+	type result Result
+	in := struct {
+		*result
+		Value json.RawMessage `json:"value"`
+	}{result: (*result)(r)}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	if len(in.Value) == 0 {
+		return nil
+	}
+	if r.Type == ArraySetterType {
+		if err := json.Unmarshal(in.Value, &r.Values); err != nil {
+			return err
+		}
+		r.Value = fmt.Sprintf("[%s]", strings.Join(r.Values, ", "))
+		return nil
+	}
+	return json.Unmarshal(in.Value, &r.Value)
+}
+
+// ReadResults reads the results of the json output format, e.g. the
+// inventory of the base render of a package to diff against
+func ReadResults(r io.Reader) ([]*Result, error) {
+	var out []*Result
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, errors.WrapPrefixf(err, "unable to read results")
+	}
+	return out, nil
+}
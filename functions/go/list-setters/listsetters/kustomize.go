@@ -0,0 +1,35 @@
+package listsetters
+
+import (
+	"path"
+	"regexp"
+)
+
+// kustomizationFileNames are the file names kustomize recognizes as kustomizations
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// inlinePatchPath matches the paths of the fields holding inline patches
+// in a kustomization e.g. patchesStrategicMerge[0] or patches[1].patch
+var inlinePatchPath = regexp.MustCompile(`^\.(patchesStrategicMerge\[\d+\]|(patches|patchesJson6902)\[\d+\]\.patch)$`)
+
+// isInlinePatch returns true if the field at path of the resource holds
+// an inline kustomize patch. Setters in inline patches are
+// discovered regardless of EmbeddedYAML as the patches are always YAML.
+func isInlinePatch(res *resource, fieldPath string) bool {
+	return isKustomization(res) && inlinePatchPath.MatchString(fieldPath)
+}
+
+// isKustomization returns true if the resource is a kustomization,
+// which is identified by its kind or, as the kind is optional, its file name
+func isKustomization(res *resource) bool {
+	if res.ref.Kind == "Kustomization" {
+		return true
+	}
+	name := path.Base(res.filePath)
+	for _, n := range kustomizationFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
@@ -1,16 +1,18 @@
 package listsetters
 
 import (
+	"context"
 	goerrors "errors"
 	"fmt"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 
 	kptfilev1 "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/kptfile/v1"
 	kptutil "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/util"
 	"sigs.k8s.io/kustomize/kyaml/errors"
-	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -27,8 +29,244 @@ type ListSetters struct {
 	// Warnings holds recoverable error info that occurred during setter discovery
 	Warnings []*WarnSetterDiscovery
 
-	// filePath file path of resource
-	filePath string
+	// OutputFormat is the format used to render the results
+	OutputFormat string
+
+	// SortBy is the order of the results, one of NameSortOrder,
+	// TypeSortOrder or CountSortOrder
+	SortBy string
+
+	// ReportUnused marks the setters declared in the Kptfile
+	// which are not used by any resource with UnusedStatus
+	ReportUnused bool
+
+	// ReportUndeclared marks the setters used by resources which
+	// are not declared in the Kptfile with UndeclaredStatus
+	ReportUndeclared bool
+
+	// PreserveOrder reports the values of array setters in the
+	// order of the document instead of sorting them
+	PreserveOrder bool
+
+	// Verbose includes the locations of the fields parameterized
+	// by the setters in the results
+	Verbose bool
+
+	// AnchorPattern matches the setter patterns against the whole
+	// field value instead of any part of it
+	AnchorPattern bool
+
+	// IgnoreCase matches the literal parts of the setter patterns
+	// case-insensitively
+	IgnoreCase bool
+
+	// PerPackage discovers the setters of each package separately,
+	// the results of each package are reported in Packages
+	PerPackage bool
+
+	// Packages holds the setters of each package if PerPackage is set
+	Packages []*PackageSetters
+
+	// Strict fails the discovery if any warnings are recorded
+	Strict bool
+
+	// ErrorOn are the categories of setter problems which fail the
+	// discovery, e.g. UnusedCategory and ConflictCategory
+	ErrorOn []string
+
+	// Summary reports the Summary of the discovered setters before the results
+	Summary bool
+
+	// IncludeResource attaches the resource owning each field to
+	// the Locations reported in verbose mode
+	IncludeResource bool
+
+	// ProvenanceAnnotation is the annotation of the resources whose value
+	// is attached to the Locations reported in verbose mode, e.g. to trace
+	// the fields back to the package which introduced them
+	ProvenanceAnnotation string
+
+	// IncludeKptfile discovers the setters declared in the Kptfile, if false
+	// setters are only discovered from the comments of the resources
+	IncludeKptfile bool
+
+	// EmbeddedYAML discovers setters in the YAML documents embedded
+	// in string values e.g. ConfigMap data
+	EmbeddedYAML bool
+
+	// DryRun reports the field changes applying the Overrides would
+	// make in Changes instead of listing the setters
+	DryRun bool
+
+	// Overrides maps setter names to the proposed values in dry-run mode
+	Overrides map[string]string
+
+	// Changes holds the field changes discovered in dry-run mode
+	Changes []Change
+
+	// SetterComment is the prefix of the line comments identifying setters,
+	// defaults to SetterCommentIdentifier
+	SetterComment string
+
+	// NamePattern is the regular expression which the names of the
+	// listed setters must match, all setters are listed if empty
+	NamePattern string
+
+	// ArraySeparator joins the values of array setters in the
+	// dotenv format, defaults to DefaultArraySeparator
+	ArraySeparator string
+
+	// SplitSetters are the names of the scalar setters holding lists as
+	// delimited values, e.g. "a,b" in legacy packages, which are reported as
+	// array setters with the split values if their value contains the
+	// SplitDelimiter. Setters aren't split by default.
+	SplitSetters []string
+
+	// SplitDelimiter splits the values of the SplitSetters,
+	// defaults to DefaultSplitDelimiter
+	SplitDelimiter string
+
+	// ArrayValueField is the field of the mapping elements of array setters
+	// reported as their value e.g. name for a list of containers. Mapping
+	// elements without it are reported as their YAML with collapsed whitespace.
+	ArrayValueField string
+
+	// MaxValuesShown is the maximum number of values of array setters shown
+	// in the text and markdown formats, the others are summarized e.g.
+	// "[a, b, ... (+498 more)]". All the values are shown if not positive,
+	// and in the JSON formats regardless.
+	MaxValuesShown int
+
+	// NameRegex is the regular expression which the names of the listed
+	// setters are expected to match, a warning is added for each setter
+	// name which doesn't match it. Names are not validated if empty.
+	NameRegex string
+
+	// Kinds are the kind or apiVersion/kind selectors of the resources to
+	// discover setters from e.g. apps/v1/Deployment, all resources are
+	// visited if empty. Setters are discovered from the Kptfile regardless.
+	Kinds []string
+
+	// Namespace is the metadata.namespace of the resources to discover setters
+	// from, e.g. the namespace of a team in a multi-tenant package, all the
+	// resources are visited if empty. Setters are discovered from the Kptfile
+	// regardless.
+	Namespace string
+
+	// ChangedFiles are the paths of the files to discover setters from, e.g.
+	// the files changed in a pull request, all files are visited if empty.
+	// Setters are discovered from the Kptfile regardless.
+	ChangedFiles []string
+
+	// IncludePaths are the glob patterns of the files to discover setters
+	// from, e.g. "apps/**", all files are visited if empty. A "**" segment
+	// matches any number of directories.
+	IncludePaths []string
+
+	// ExcludePaths are the glob patterns of the files to skip in setter
+	// discovery, e.g. "**/test/**" to skip test fixtures.
+	ExcludePaths []string
+
+	// IgnoreAnnotations maps the annotations of the resources to skip in
+	// setter discovery to their values, e.g. local-config scaffolding, an
+	// empty value matches any value. Setters are still declared by them.
+	IgnoreAnnotations map[string]string
+
+	// Constraints maps array setter names to the constraints of their values
+	Constraints map[string]SetterConstraint
+
+	// Violations holds the array setter fields violating the Constraints
+	Violations []*ConstraintViolation
+
+	// DetectUntagged finds the fields holding the value of a scalar
+	// setter without a setter comment and records them in Untagged
+	DetectUntagged bool
+
+	// Untagged holds the candidate unparameterized fields found if
+	// DetectUntagged is set
+	Untagged []*UntaggedField
+
+	// Verify checks that re-applying the discovered setter values to the
+	// fields parameterized by them is a no-op, the fields which would be
+	// changed are recorded in Mismatches
+	Verify bool
+
+	// Mismatches holds the fields which would be changed by re-applying
+	// the discovered setter values if Verify is set
+	Mismatches []*Mismatch
+
+	// verified are the fields checked if Verify is set
+	verified []verifiedField
+
+	// AmbiguousPatterns holds the setter patterns with adjacent
+	// setter references whose values can't be derived reliably
+	AmbiguousPatterns []*AmbiguousPattern
+
+	// Debug records the setter discovery decisions made for
+	// the fields with line comments in Trace
+	Debug bool
+
+	// Trace holds the setter discovery decisions if Debug is set
+	Trace []*TraceEntry
+
+	// Syntax identifies the setter references in the setter
+	// patterns, DollarBraceSyntax is used if nil
+	Syntax SetterSyntax
+
+	// ReportStats reports the Stats of the scanned documents before the results
+	ReportStats bool
+
+	// Stats holds the statistics of the documents scanned by Filter
+	Stats Stats
+
+	// Mode is the discovery mode, FullMode if empty. CountMode only counts
+	// the fields parameterized by each setter, which is faster for large
+	// packages as the setter values aren't resolved from the field values.
+	// ResolveMode only reports whether the setter values are resolved.
+	Mode string
+
+	// MaxDepth is the maximum nesting depth of the fields scanned for setters,
+	// 1 for the top level fields e.g. metadata, deeper fields are not scanned.
+	// The depth is unlimited if not positive.
+	MaxDepth int
+
+	// MaxWarnings is the maximum number of Warnings listed, the others are
+	// summarized by a single TruncatedWarnings warning. The number of
+	// warnings is unlimited if not positive.
+	MaxWarnings int
+
+	// InventoryPath is the path of the inventory resource of the discovered
+	// setters appended to the nodes returned by Filter, relative to the
+	// package e.g. setters.yaml. The resource found at the path in the
+	// input is replaced. No inventory resource is generated if empty.
+	InventoryPath string
+
+	// InventoryKind is the kind of the inventory resource, one of
+	// ConfigMapInventoryKind, the default, or SetterInventoryKind
+	InventoryKind string
+
+	// Workers is the maximum number of resources visited concurrently,
+	// defaults to GOMAXPROCS if not positive
+	Workers int
+
+	// nameRegex is the compiled NamePattern
+	nameRegex *regexp.Regexp
+
+	// nameValidator is the compiled NameRegex
+	nameValidator *regexp.Regexp
+
+	// kfSetters holds the setters declared in the Kptfile
+	kfSetters map[string]string
+
+	// kfPath is the path of the Kptfile of the root package, empty if not found
+	kfPath string
+
+	// matchers caches the compiled setter patterns
+	matchers map[matcherKey]*setterMatcher
+
+	// unresolvedUpstream is the upstream reference of the last Kptfile
+	// in the upstream chain which couldn't be found in the input
+	unresolvedUpstream string
 }
 
 // ScalarSetter stores name, value and count of the scalar setter
@@ -42,8 +280,57 @@ type ScalarSetter struct {
 	// Type is the data type for the value
 	Type string
 
+	// ValueType is the type the value resolves to as a plain YAML
+	// scalar, one of int, bool, float, null or string
+	ValueType string
+
 	// Count is the number of fields parameterized by the setter
 	Count int
+
+	// Files maps the file paths where the setter is used to the
+	// number of fields parameterized by the setter in that file
+	Files map[string]int
+
+	// Resources maps the identities of the resources where the setter is used
+	// to the number of fields parameterized by the setter in that resource
+	Resources map[string]int
+
+	// Source is the Kptfile pipeline step declaring the setter
+	// e.g. pipeline.mutators[1], empty if the setter is not declared
+	Source string
+
+	// Locations are the locations of the fields parameterized by the setter
+	Locations []Location
+
+	// DistinctValues maps the distinct values of the fields parameterized
+	// by the setter to the file paths where each value is used
+	DistinctValues map[string][]string
+
+	// DeclaredValue is the value declared in the Kptfile
+	DeclaredValue string
+
+	// AppliedValue is the value of the first field parameterized by the setter
+	AppliedValue string
+
+	// Overridden is true if the setter is declared in the Kptfile and
+	// the AppliedValue differs from the DeclaredValue
+	Overridden bool
+
+	// Default is the default value of the setter in the setter comment
+	// e.g. latest for ${tag:-latest}
+	Default string
+
+	// Empty is true if the setter resolves to an empty value in any of
+	// the fields it parameterizes, which is distinct from being unset
+	Empty bool
+
+	// Inconsistencies are the documents in which the fields
+	// parameterized by the setter have different values
+	Inconsistencies []Inconsistency
+
+	// documentFields maps the documents to the fields parameterized
+	// by the setter in them
+	documentFields map[documentKey][]FieldValue
 }
 
 // ArraySetter stores name, values and count of the array setter
@@ -56,25 +343,373 @@ type ArraySetter struct {
 
 	// Count is the number of fields parameterized by the setter
 	Count int
+
+	// Files maps the file paths where the setter is used to the
+	// number of fields parameterized by the setter in that file
+	Files map[string]int
+
+	// Resources maps the identities of the resources where the setter is used
+	// to the number of fields parameterized by the setter in that resource
+	Resources map[string]int
+
+	// Source is the Kptfile pipeline step declaring the setter
+	// e.g. pipeline.mutators[1], empty if the setter is not declared
+	Source string
+
+	// Locations are the locations of the fields parameterized by the setter
+	Locations []Location
 }
 
 // Result represents results of setter discovery
 type Result struct {
-	Name  string
-	Value string
-	Type  string
-	Count int
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+
+	// ValueType is the type the value of a scalar setter resolves
+	// to, one of int, bool, float, null or string
+	ValueType string `json:"valueType,omitempty"`
+
+	// FieldCount is the number of fields parameterized by the setter,
+	// Count is kept as an alias of it for compatibility
+	FieldCount int `json:"fieldCount"`
+
+	// ResourceCount is the number of resources with fields parameterized by the setter
+	ResourceCount int `json:"resourceCount"`
+
+	// Files are the sorted distinct file paths where the setter is used
+	Files []string `json:"files,omitempty"`
+
+	// Status reports problems found with the setter e.g. UnusedStatus
+	Status string `json:"status,omitempty"`
+
+	// Overridden is true if the value of a scalar setter in the
+	// resources differs from the value declared in the Kptfile
+	Overridden bool `json:"overridden,omitempty"`
+
+	// Default is the default value of a scalar setter in the setter comment
+	Default string `json:"default,omitempty"`
+
+	// Empty is true if a scalar setter resolves to an empty value in any
+	// of the fields it parameterizes
+	Empty bool `json:"empty,omitempty"`
+
+	// Inconsistencies are the documents in which the fields parameterized
+	// by a scalar setter have different values
+	Inconsistencies []Inconsistency `json:"inconsistencies,omitempty"`
+
+	// Source is the Kptfile pipeline step declaring the setter e.g.
+	// pipeline.mutators[0], empty if the setter is not declared
+	Source string `json:"source,omitempty"`
+
+	// Locations are the locations of the fields parameterized by the setter,
+	// only populated in verbose mode
+	Locations []Location `json:"locations,omitempty"`
+
+	// ValueBytes is the total size of the values set by the setter, the
+	// length of its value times its count, only populated in verbose mode
+	ValueBytes int `json:"valueBytes,omitempty"`
+
+	// Values holds the individual values of an array setter
+	Values []string `json:"-"`
+
+	// DependsOn are the sorted names of the setters referenced in the value of
+	// the setter, which need another apply-setters pass to be substituted
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Resolved is false if the value of the setter still contains a setter
+	// reference, e.g. a placeholder ${project}, only populated in ResolveMode
+	Resolved bool `json:"-"`
 }
 
 func (r Result) String() string {
-	return fmt.Sprintf("Name: %s, Value: %s, Type: %s, Count: %d", r.Name, r.Value, r.Type, r.Count)
+	s := fmt.Sprintf("Name: %s, Value: %s, Type: %s, Count: %d", r.Name, r.Value, r.Type, r.Count)
+	if r.Default != "" {
+		s += fmt.Sprintf(", Default: %s", r.Default)
+	}
+	if r.Overridden {
+		s += ", Overridden: true"
+	}
+	if r.Empty {
+		s += ", Empty: true"
+	}
+	if len(r.Inconsistencies) > 0 {
+		s += ", Inconsistent: true"
+	}
+	if len(r.DependsOn) > 0 {
+		s += fmt.Sprintf(", Depends on: [%s]", strings.Join(r.DependsOn, ", "))
+	}
+	if r.Source != "" {
+		s += fmt.Sprintf(", Source: %s", r.Source)
+	}
+	if len(r.Locations) > 0 {
+		locs := make([]string, len(r.Locations))
+		for i := range r.Locations {
+			locs[i] = r.Locations[i].String()
+		}
+		s += fmt.Sprintf(", Locations: [%s]", strings.Join(locs, ", "))
+	}
+	if r.ValueBytes > 0 {
+		s += fmt.Sprintf(", Value bytes: %d", r.ValueBytes)
+	}
+	return s
+}
+
+// StatusMessage describes the problem flagged by the Status of the
+// setter, empty if the setter has no status
+func (r Result) StatusMessage() string {
+	switch r.Status {
+	case UnusedStatus:
+		return unusedMessage(r.Name)
+	case UndeclaredStatus:
+		return undeclaredMessage(r.Name)
+	}
+	return ""
+}
+
+// unusedMessage describes the setter declared in the Kptfile but not used
+func unusedMessage(name string) string {
+	return fmt.Sprintf("setter %q is declared in the Kptfile but not used by any resource", name)
+}
+
+// undeclaredMessage describes the setter used by resources but not declared in the Kptfile
+func undeclaredMessage(name string) string {
+	return fmt.Sprintf("setter %q is used by resources but not declared in the Kptfile", name)
+}
+
+// Summary holds the aggregate counts of the listed setters
+type Summary struct {
+	// Setters is the number of distinct setters
+	Setters int `json:"setters"`
+
+	// ScalarSetters is the number of setters which are not array setters
+	ScalarSetters int `json:"scalarSetters"`
+
+	// ArraySetters is the number of array setters
+	ArraySetters int `json:"arraySetters"`
+
+	// Occurrences is the number of fields parameterized by all the setters
+	Occurrences int `json:"occurrences"`
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("Summary: Setters: %d, Scalar: %d, Array: %d, Occurrences: %d",
+		s.Setters, s.ScalarSetters, s.ArraySetters, s.Occurrences)
+}
+
+// HistogramBucket holds the number of setters whose
+// count is in the range of the bucket
+type HistogramBucket struct {
+	// Label is the range of counts of the bucket e.g. 3-5
+	Label string `json:"label"`
+
+	// Setters is the number of setters with a count in the range
+	Setters int `json:"setters"`
+}
+
+func (b HistogramBucket) String() string {
+	return fmt.Sprintf("Count: %s, Setters: %d", b.Label, b.Setters)
+}
+
+// histogramBuckets are the lower bounds and labels of the usage histogram
+// buckets in ascending order, each bucket ends before the next one starts
+var histogramBuckets = []struct {
+	min   int
+	label string
+}{{0, "0"}, {1, "1"}, {2, "2"}, {3, "3-5"}, {6, "6+"}}
+
+// Location identifies a field parameterized by a setter
+type Location struct {
+	// File is the file path of the resource
+	File string `json:"file"`
+
+	// Line is the line number of the field relative to the first field
+	// of the resource, so it's only the line in the file for the first
+	// document of the file
+	Line int `json:"line"`
+
+	// Column is the column number of the field relative to the
+	// indentation of the resource
+	Column int `json:"column"`
+
+	// Document is the index of the resource document in the file,
+	// starting from 0 for the first document
+	Document int `json:"document,omitempty"`
+
+	// Path is the path of the field in the resource including the
+	// indices of sequence elements e.g. spec.containers[0].image
+	Path string `json:"path,omitempty"`
+
+	// Resource is the resource owning the field, only
+	// populated if IncludeResource is set
+	Resource *ResourceRef `json:"resource,omitempty"`
+
+	// Provenance is the value of the ProvenanceAnnotation of the resource
+	// owning the field, empty if the resource doesn't carry the annotation
+	Provenance string `json:"provenance,omitempty"`
+}
+
+func (l Location) String() string {
+	s := fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+	if l.Path != "" {
+		s += " " + l.Path
+	}
+	if l.Document > 0 {
+		s += fmt.Sprintf(" in document %d", l.Document)
+	}
+	if l.Resource != nil {
+		s += fmt.Sprintf(" (%s)", l.Resource)
+	}
+	if l.Provenance != "" {
+		s += fmt.Sprintf(" from %s", l.Provenance)
+	}
+	return s
+}
+
+// ResourceRef identifies the resource owning a field parameterized by a setter
+type ResourceRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// String returns the compact Kind/name.namespace form of the reference
+// e.g. Deployment/foo.ns, the namespace is omitted if empty
+func (r ResourceRef) String() string {
+	s := fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	if r.Namespace != "" {
+		s += "." + r.Namespace
+	}
+	return s
+}
+
+// Inconsistency describes the fields of a document which are
+// parameterized by the same setter but have different values
+type Inconsistency struct {
+	// File is the file path of the document
+	File string `json:"file"`
+
+	// Resource is the resource of the document in the
+	// Kind/name.namespace form e.g. Deployment/foo.ns
+	Resource string `json:"resource"`
+
+	// Fields are the fields parameterized by the setter in document order
+	Fields []FieldValue `json:"fields"`
+}
+
+// FieldValue is the value of a field parameterized by a setter
+type FieldValue struct {
+	// Path is the path of the field in the document e.g. spec.replicas
+	Path string `json:"path"`
+
+	// Value is the value the setter resolves to in the field
+	Value string `json:"value"`
+}
+
+// documentKey identifies a document of the input
+type documentKey struct {
+	file     string
+	resource string
 }
 
 // WarnSetterDiscovery represents a recoverable error that occurred during setter discovery
 type WarnSetterDiscovery struct {
+	// Reason categorizes the warning so that callers can handle it programmatically
+	Reason WarningReason
+
 	message string
+
+	// File is the file path of the resource the warning is about,
+	// empty if the warning isn't about a resource
+	File string
+
+	// Path is the path of the field the warning is about e.g. spec.replicas,
+	// empty if the warning isn't about a single field
+	Path string
 }
 
+// WarningReason is the reason of a WarnSetterDiscovery
+type WarningReason string
+
+const (
+	// KptfileNotFound is the reason of the warnings about a missing Kptfile
+	KptfileNotFound WarningReason = "KptfileNotFound"
+
+	// NoPipeline is the reason of the warnings about a Kptfile without pipeline
+	NoPipeline WarningReason = "NoPipeline"
+
+	// NoApplySetters is the reason of the warnings about a Kptfile
+	// pipeline without apply-setters mutator
+	NoApplySetters WarningReason = "NoApplySetters"
+
+	// NoFunctionConfig is the reason of the warnings about an
+	// apply-setters mutator without setters config
+	NoFunctionConfig WarningReason = "NoFunctionConfig"
+
+	// ConfigPathMissing is the reason of the warnings about a
+	// configPath of apply-setters which is not part of the input
+	ConfigPathMissing WarningReason = "ConfigPathMissing"
+
+	// Ambiguous is the reason of the warnings about multiple Kptfiles of the
+	// root package or multiple setters configs of an apply-setters mutator
+	Ambiguous WarningReason = "Ambiguous"
+
+	// UnresolvedUpstream is the reason of the warnings about
+	// an upstream Kptfile which is not part of the input
+	UnresolvedUpstream WarningReason = "UnresolvedUpstream"
+
+	// InvalidConfig is the reason of the warnings about the functionConfig
+	InvalidConfig WarningReason = "InvalidConfig"
+
+	// InvalidValue is the reason of the warnings about the dry-run setter values
+	InvalidValue WarningReason = "InvalidValue"
+
+	// InvalidName is the reason of the warnings about setter names
+	// which don't match the NameRegex
+	InvalidName WarningReason = "InvalidName"
+
+	// MalformedPattern is the reason of the warnings about malformed setter patterns
+	MalformedPattern WarningReason = "MalformedPattern"
+
+	// AmbiguousCapture is the reason of the warnings about setter patterns
+	// with adjacent setter references, e.g. ${a}${b}
+	AmbiguousCapture WarningReason = "AmbiguousCapture"
+
+	// InvalidEmbeddedDocument is the reason of the warnings about
+	// embedded YAML documents which can't be parsed
+	InvalidEmbeddedDocument WarningReason = "InvalidEmbeddedDocument"
+
+	// ConflictingValues is the reason of the warnings about scalar
+	// setters parameterizing fields with different values
+	ConflictingValues WarningReason = "ConflictingValues"
+
+	// InconsistentValues is the reason of the warnings about scalar setters
+	// parameterizing fields with different values in the same document
+	InconsistentValues WarningReason = "InconsistentValues"
+
+	// KindMismatch is the reason of the warnings about setters
+	// used both as scalar and array setters
+	KindMismatch WarningReason = "KindMismatch"
+
+	// ArrayValuesMismatch is the reason of the warnings about array setters whose
+	// values differ from the values declared in the Kptfile
+	ArrayValuesMismatch WarningReason = "ArrayValuesMismatch"
+
+	// UnresolvedDependency is the reason of the warnings about setters
+	// whose values reference other setters
+	UnresolvedDependency WarningReason = "UnresolvedDependency"
+
+	// DuplicateSetters is the reason of the warnings about setters
+	// declared by multiple apply-setters mutators of a Kptfile
+	DuplicateSetters WarningReason = "DuplicateSetters"
+
+	// TruncatedWarnings is the reason of the warning summarizing
+	// the warnings which are not listed as MaxWarnings is exceeded
+	TruncatedWarnings WarningReason = "TruncatedWarnings"
+)
+
 func (e *WarnSetterDiscovery) Error() string {
 	return e.message
 }
@@ -82,57 +717,206 @@ func (e *WarnSetterDiscovery) Error() string {
 const (
 	ArraySetterType         string = "array"
 	ScalarSetterDefaultType string = "str"
+	KeySetterType           string = "key"
+)
+
+// ValueType values of scalar setters
+const (
+	IntValueType    = "int"
+	BoolValueType   = "bool"
+	FloatValueType  = "float"
+	NullValueType   = "null"
+	StringValueType = "string"
+)
+
+const (
+	// UnusedStatus is the status of a setter declared in the Kptfile
+	// which doesn't parameterize any field
+	UnusedStatus = "unused"
+
+	// UndeclaredStatus is the status of a setter parameterizing fields
+	// which is not declared in the Kptfile
+	UndeclaredStatus = "undeclared"
 )
 
 // FindKptfile discovers Kptfile of the root package from slice of nodes
 func FindKptfile(nodes []*yaml.RNode) (*kptfilev1.KptFile, error) {
+	node, err := findKptfileNode(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return decodeKptfile(node)
+}
+
+// findKptfileNode finds the Kptfile node of the root package, which is the Kptfile at
+// the root of the input or else the only Kptfile in the directory closest to the root
+func findKptfileNode(nodes []*yaml.RNode) (*yaml.RNode, error) {
+	var found []*yaml.RNode
+	depth := -1
 	for _, node := range nodes {
-		if node.GetAnnotations()[kioutil.PathAnnotation] == kptfilev1.KptFileName {
-			s, err := node.String()
-			if err != nil {
-				return nil, errors.WrapPrefixf(err, "unable to read Kptfile")
-			}
-			kf, err := kptutil.DecodeKptfile(s)
-			return kf, errors.WrapPrefixf(err, "unable to read Kptfile")
+		np := nodePath(node)
+		if path.Base(np) != kptfilev1.KptFileName {
+			continue
+		}
+		d := strings.Count(path.Clean(np), "/")
+		if depth == -1 || d < depth {
+			found, depth = []*yaml.RNode{node}, d
+		} else if d == depth {
+			found = append(found, node)
+		}
+	}
+	if len(found) > 1 {
+		var paths []string
+		for _, node := range found {
+			paths = append(paths, nodePath(node))
+		}
+		return nil, &WarnSetterDiscovery{Reason: Ambiguous, message: fmt.Sprintf("unable to find Kptfile of the root package, found multiple Kptfiles %s", strings.Join(paths, ", "))}
+	}
+	if len(found) == 0 {
+		return nil, &WarnSetterDiscovery{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}
+	}
+	return found[0], nil
+}
+
+// decodeKptfile decodes the Kptfile node, inline functionConfig of the
+// pipeline mutators isn't part of the typed Kptfile and is dropped
+func decodeKptfile(node *yaml.RNode) (*kptfilev1.KptFile, error) {
+	node, _, err := splitFunctionConfigs(node)
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "unable to read Kptfile")
+	}
+	s, err := node.String()
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "unable to read Kptfile")
+	}
+	kf, err := kptutil.DecodeKptfile(s)
+	return kf, errors.WrapPrefixf(err, "unable to read Kptfile")
+}
+
+// splitFunctionConfigs returns a copy of the Kptfile node without the inline
+// functionConfig of the pipeline mutators, and the removed functionConfig
+// nodes keyed by the index of their mutator
+func splitFunctionConfigs(node *yaml.RNode) (*yaml.RNode, map[int]*yaml.RNode, error) {
+	node = node.Copy()
+	mutators, err := node.Pipe(yaml.Lookup("pipeline", "mutators"))
+	if err != nil || mutators == nil || mutators.YNode().Kind != yaml.SequenceNode {
+		return node, nil, err
+	}
+	fnConfigs := make(map[int]*yaml.RNode)
+	for i, fn := range mutators.Content() {
+		fnNode := yaml.NewRNode(fn)
+		fnConfig, err := fnNode.Pipe(yaml.Get("functionConfig"))
+		if err != nil || fnConfig == nil {
+			continue
 		}
+		if err := fnNode.PipeE(yaml.Clear("functionConfig")); err != nil {
+			return nil, nil, err
+		}
+		fnConfigs[i] = fnConfig
 	}
-	return nil, &WarnSetterDiscovery{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}
+	return node, fnConfigs, nil
 }
 
-// FindSettersFromKptfile discovers setters from kptfile if exists
+// FindSettersFromKptfile discovers setters from kptfile if exists. If setters
+// are declared multiple times, they are returned along with a DuplicateSetters
+// WarnSetterDiscovery listing the declarations.
 func FindSettersFromKptfile(nodes []*yaml.RNode) (map[string]string, error) {
-	kf, err := FindKptfile(nodes)
+	kfSetters, _, err := findKptfileSetters(nodes)
+	return kfSetters, err
+}
+
+// findKptfileSetters implements FindSettersFromKptfile, it additionally returns
+// the apply-setters mutator step each setter is declared in e.g. pipeline.mutators[1]
+func findKptfileSetters(nodes []*yaml.RNode) (map[string]string, map[string]string, error) {
+	kfNode, err := findKptfileNode(nodes)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return kptfileSetters(nodes, kfNode)
+}
+
+// kptfileSetters returns the setters declared in the Kptfile node and the
+// apply-setters mutator step each setter is declared in
+func kptfileSetters(nodes []*yaml.RNode, kfNode *yaml.RNode) (map[string]string, map[string]string, error) {
+	kf, err := decodeKptfile(kfNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, fnConfigs, err := splitFunctionConfigs(kfNode)
+	if err != nil {
+		return nil, nil, errors.WrapPrefixf(err, "unable to read Kptfile")
 	}
+	// ConfigPath is relative to the directory of the Kptfile
+	kfDir := path.Dir(nodePath(kfNode))
 	if kf.Pipeline == nil {
-		return nil, &WarnSetterDiscovery{"unable to find Pipeline declaration in Kptfile"}
+		return nil, nil, &WarnSetterDiscovery{Reason: NoPipeline, message: "unable to find Pipeline declaration in Kptfile"}
 	}
 
-	// kfSetters accumulates setters if there are multiple declarations of apply-setters function
+	// kfSetters accumulates setters if there are multiple declarations of apply-setters function,
+	// setters declared in later steps override the ones declared in earlier steps
 	var kfSetters map[string]string
-	for _, fn := range kf.Pipeline.Mutators {
+	sources := make(map[string]string)
+	// declarations holds the declarations of each setter e.g. "1.8.0" in pipeline.mutators[1]
+	declarations := make(map[string][]string)
+	for i, fn := range kf.Pipeline.Mutators {
 		if !strings.Contains(fn.Image, "apply-setters") {
 			continue
 		}
-		if fn.ConfigMap != nil {
-			kfSetters = mergeSetters(kfSetters, fn.ConfigMap)
+		var stepSetters map[string]string
+		fnConfig := fnConfigs[i]
+		if fn.ConfigMap != nil && fn.ConfigPath != "" {
+			return nil, nil, &WarnSetterDiscovery{Reason: Ambiguous, message: fmt.Sprintf(
+				"apply-setters fn in pipeline.mutators[%d] declares both ConfigMap and ConfigPath fnConfig, please declare only one of them", i)}
+		}
+		if fnConfig != nil && (fn.ConfigMap != nil || fn.ConfigPath != "") {
+			return nil, nil, &WarnSetterDiscovery{Reason: Ambiguous, message: fmt.Sprintf(
+				"apply-setters fn in pipeline.mutators[%d] declares both inline functionConfig and ConfigMap or ConfigPath fnConfig, please declare only one of them", i)}
+		}
+		if fnConfig != nil {
+			if data, err := fnConfig.Pipe(yaml.Lookup(yaml.DataField)); err != nil || data == nil {
+				return nil, nil, &WarnSetterDiscovery{Reason: NoFunctionConfig, message: fmt.Sprintf(
+					"unable to find data of the inline functionConfig of apply-setters fn in pipeline.mutators[%d]", i)}
+			}
+			stepSetters = fnConfig.GetDataMap()
+		} else if fn.ConfigMap != nil {
+			stepSetters = fn.ConfigMap
 		} else if fn.ConfigPath != "" {
-			settersConfig, err := findSetterNode(nodes, fn.ConfigPath)
+			settersConfig, err := findSetterNode(nodes, path.Join(kfDir, slashPath(fn.ConfigPath)))
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			kfSetters = mergeSetters(kfSetters, settersConfig.GetDataMap())
+			stepSetters = settersConfig.GetDataMap()
 		} else {
-			return nil, &WarnSetterDiscovery{"unable to find ConfigMap or ConfigPath fnConfig for apply-setters"}
+			return nil, nil, &WarnSetterDiscovery{Reason: NoFunctionConfig, message: "unable to find ConfigMap or ConfigPath fnConfig for apply-setters"}
+		}
+		kfSetters = mergeSetters(kfSetters, stepSetters)
+		for name, value := range stepSetters {
+			sources[name] = fmt.Sprintf("pipeline.mutators[%d]", i)
+			declarations[name] = append(declarations[name], fmt.Sprintf("%q in %s", value, sources[name]))
 		}
-
 	}
 
 	if len(kfSetters) > 0 {
-		return kfSetters, nil
+		return kfSetters, sources, duplicateSettersWarning(declarations)
+	}
+	return nil, nil, &WarnSetterDiscovery{Reason: NoApplySetters, message: "unable to find apply-setters fn in Kptfile Pipeline.Mutators"}
+}
+
+// duplicateSettersWarning returns a DuplicateSetters warning listing the
+// setters with multiple declarations, or nil if there are none
+func duplicateSettersWarning(declarations map[string][]string) error {
+	var duplicates []string
+	for name, decls := range declarations {
+		if len(decls) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s is declared as %s", name, strings.Join(decls, " and ")))
+		}
+	}
+	if len(duplicates) == 0 {
+		return nil
 	}
-	return nil, &WarnSetterDiscovery{"unable to find apply-setters fn in Kptfile Pipeline.Mutators"}
+	sort.Strings(duplicates)
+	return &WarnSetterDiscovery{Reason: DuplicateSetters, message: fmt.Sprintf(
+		"setters are declared by multiple apply-setters fns in Kptfile, the last declaration is used: %s", strings.Join(duplicates, "; "))}
 }
 
 // mergeSetters merges two setter maps a and b
@@ -151,17 +935,26 @@ func mergeSetters(a, b map[string]string) map[string]string {
 //findSetterNode finds setter node from nodes
 func findSetterNode(nodes []*yaml.RNode, path string) (*yaml.RNode, error) {
 	for _, node := range nodes {
-		np := node.GetAnnotations()[kioutil.PathAnnotation]
+		np := nodePath(node)
 		if np == path {
 			return node, nil
 		}
 	}
-	return nil, &WarnSetterDiscovery{fmt.Sprintf(`file %s doesn't exist, please ensure the file specified in "configPath" exists and retry`, path)}
+	return nil, &WarnSetterDiscovery{Reason: ConfigPathMissing, message: fmt.Sprintf(`file %s doesn't exist, please ensure the file specified in "configPath" exists and retry`, path)}
 }
 
 // getArraySetterValues attempts to parse an array setter value
-// wrapped as string to a slice of strings
+// wrapped as string to a slice of strings. The elements are trimmed and the
+// whitespace within plain elements is collapsed to a single space e.g.
+// "[ a , b  c ]" returns [a, "b c"]. Quoted elements are unquoted but kept
+// verbatim otherwise, so they may contain commas e.g. '["a, b", c]'.
 func getArraySetterValues(sv string) ([]string, error) {
+	return getArraySetterFieldValues(sv, "")
+}
+
+// getArraySetterFieldValues implements getArraySetterValues, the mapping
+// elements are reported as the value of their field if it's not empty
+func getArraySetterFieldValues(sv, field string) ([]string, error) {
 	rn, err := yaml.Parse(sv)
 	if err != nil {
 		return nil, err
@@ -172,93 +965,510 @@ func getArraySetterValues(sv string) ([]string, error) {
 	}
 	setterVals := make([]string, len(elems))
 	for i, elem := range elems {
-		setterVal, err := elem.String()
-		if err != nil {
-			return nil, err
+		if elem.YNode().Kind == yaml.ScalarNode && elem.YNode().Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			setterVals[i] = elem.YNode().Value
+			continue
+		}
+		setterVal := elem.YNode().Value
+		if elem.YNode().Kind != yaml.ScalarNode {
+			if setterVal, err = arrayElementValue(elem, field); err != nil {
+				return nil, err
+			}
 		}
-		setterVals[i] = strings.ReplaceAll(setterVal, "\n", "")
+		setterVals[i] = strings.Join(strings.Fields(setterVal), " ")
 	}
 	return setterVals, nil
 }
 
 func New() ListSetters {
-	ls := ListSetters{}
+	ls := ListSetters{OutputFormat: TextOutputFormat, SetterComment: SetterCommentIdentifier, SortBy: NameSortOrder, IncludeKptfile: true,
+		ArraySeparator: DefaultArraySeparator}
 	ls.ArraySetters = make(map[string]*ArraySetter)
 	ls.ScalarSetters = make(map[string]*ScalarSetter)
 	return ls
 }
 
-//addKptfileSetters parses setters in fn config to ArraySetters or ScalarSetters
-func (ls *ListSetters) addKptfileSetters(s map[string]string) {
+//addKptfileSetters parses setters in fn config to ArraySetters or ScalarSetters,
+//sources maps the setter names to the pipeline step declaring them
+func (ls *ListSetters) addKptfileSetters(s, sources map[string]string) {
 	for setterName, setterValue := range s {
-		v, err := getArraySetterValues(setterValue)
+		v, err := getArraySetterFieldValues(setterValue, ls.ArrayValueField)
 		if err == nil {
-			ls.ArraySetters[setterName] = &ArraySetter{Name: setterName, Values: v, Count: 0}
+			ls.ArraySetters[setterName] = &ArraySetter{Name: setterName, Values: v, Count: 0, Files: make(map[string]int), Resources: make(map[string]int), Source: sources[setterName]}
 		} else {
-			ls.ScalarSetters[setterName] = &ScalarSetter{Name: setterName, Value: setterValue, Type: ScalarSetterDefaultType, Count: 0}
+			ls.ScalarSetters[setterName] = &ScalarSetter{Name: setterName, Value: setterValue, Type: ScalarSetterDefaultType, ValueType: inferValueType(setterValue), Count: 0, Files: make(map[string]int), Resources: make(map[string]int), Source: sources[setterName], DeclaredValue: setterValue}
 		}
 	}
 }
 
+// orderedValues returns the values of an array setter in the order they are
+// reported regardless of whether they are discovered from the resources or
+// the Kptfile, sorted unless PreserveOrder is set. The values are copied so
+// sorting them doesn't reorder the values of the setter.
+func (ls *ListSetters) orderedValues(values []string) []string {
+	if ls.PreserveOrder || values == nil {
+		return values
+	}
+	out := make([]string, len(values))
+	copy(out, values)
+	sort.Strings(out)
+	return out
+}
+
 // GetResults returns sorted slice of all listsetter results
 func (ls *ListSetters) GetResults() []*Result {
 	var out []*Result
 	for _, v := range ls.ArraySetters {
-		out = append(out, &Result{Name: v.Name, Value: fmt.Sprintf("[%s]", strings.Join(v.Values, ", ")), Count: v.Count, Type: ArraySetterType})
+		if !ls.matchesName(v.Name) {
+			continue
+		}
+		values := ls.orderedValues(v.Values)
+		r := &Result{Name: v.Name, Value: fmt.Sprintf("[%s]", strings.Join(values, ", ")), Values: values, Count: v.Count, FieldCount: v.Count, ResourceCount: len(v.Resources), Type: ArraySetterType, Files: sortedFiles(v.Files),
+			Source: v.Source}
+		if ls.Verbose || ls.SortBy == LocationSortOrder {
+			r.Locations = v.Locations
+		}
+		out = append(out, r)
 	}
 	for _, v := range ls.ScalarSetters {
-		out = append(out, &Result{Name: v.Name, Value: v.Value, Count: v.Count, Type: v.Type})
+		if !ls.matchesName(v.Name) {
+			continue
+		}
+		r := &Result{Name: v.Name, Value: v.Value, Count: v.Count, FieldCount: v.Count, ResourceCount: len(v.Resources), Type: v.Type, ValueType: v.ValueType, Files: sortedFiles(v.Files), Overridden: v.Overridden, Default: v.Default,
+			Empty: v.Empty, Inconsistencies: v.Inconsistencies, Source: v.Source}
+		if values, ok := ls.splitValues(v.Name, v.Value); ok {
+			r.Values = ls.orderedValues(values)
+			r.Value = fmt.Sprintf("[%s]", strings.Join(r.Values, ", "))
+			r.Type = ArraySetterType
+			r.ValueType = ""
+		}
+		if ls.Verbose || ls.SortBy == LocationSortOrder {
+			r.Locations = v.Locations
+		}
+		out = append(out, r)
+	}
+	for _, r := range out {
+		if ls.Verbose {
+			r.ValueBytes = valueBytes(r)
+		}
+		r.DependsOn = ls.dependencies(r)
+		if ls.Mode == ResolveMode {
+			r.Resolved = ls.resolved(r)
+		}
+		if ls.ReportUnused && ls.unused(r) {
+			r.Status = UnusedStatus
+		} else if ls.ReportUndeclared && ls.undeclared(r) {
+			r.Status = UndeclaredStatus
+		}
 	}
-	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	sort.Slice(out, func(i, j int) bool { return ls.lessResult(out[i], out[j]) })
 	return out
 }
 
-// Filter implements list as a yaml.Filter
-func (ls *ListSetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
-	// attempt to discover setters from Kptfile
-	kfSetters, err := FindSettersFromKptfile(nodes)
-	if err != nil {
-		var discoveryWarning *WarnSetterDiscovery
-		if ok := goerrors.As(err, &discoveryWarning); ok {
-			ls.Warnings = append(ls.Warnings, discoveryWarning)
-		} else {
-			return nodes, err
+// valueBytes returns the total size of the values set by the setter of the
+// result, the values of an array setter are summed without the brackets
+func valueBytes(r *Result) int {
+	size := len(r.Value)
+	if r.Type == ArraySetterType {
+		size = 0
+		for _, v := range r.Values {
+			size += len(v)
 		}
 	}
-	if kfSetters != nil {
-		ls.addKptfileSetters(kfSetters)
+	return size * r.Count
+}
+
+// lessResult orders the results by the key of the SortBy order, ties are
+// broken by name and then by type, so that the order doesn't depend on the
+// iteration order of the setter maps, e.g. a scalar and an array setter
+// sharing a name
+func (ls *ListSetters) lessResult(a, b *Result) bool {
+	switch ls.SortBy {
+	case TypeSortOrder:
+		if isArray, otherIsArray := a.Type == ArraySetterType, b.Type == ArraySetterType; isArray != otherIsArray {
+			return otherIsArray
+		}
+	case CountSortOrder:
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+	case LocationSortOrder:
+		if la, lb := firstLocation(a), firstLocation(b); la.File != lb.File || la.Path != lb.Path {
+			return la.File < lb.File || la.File == lb.File && la.Path < lb.Path
+		}
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Type < b.Type
+}
+
+// unused returns true if the setter of the result is not used by any resource,
+// only the setters declared in the Kptfile can be unused
+func (ls *ListSetters) unused(r *Result) bool {
+	return r.Count == 0
+}
+
+// undeclared returns true if the setter of the result is not declared in the
+// Kptfile, setters can only be undeclared if setters are declared in the Kptfile
+func (ls *ListSetters) undeclared(r *Result) bool {
+	_, declared := ls.kfSetters[r.Name]
+	return ls.kfSetters != nil && !declared
+}
+
+// conflicting returns the scalar setter of the result if it
+// parameterizes fields with different values, nil otherwise
+func (ls *ListSetters) conflicting(r *Result) *ScalarSetter {
+	if s, ok := ls.ScalarSetters[r.Name]; ok && r.Type != ArraySetterType && len(s.DistinctValues) > 1 {
+		return s
+	}
+	return nil
+}
+
+// Summarize returns the Summary of the setters listed by GetResults,
+// the setters of all the packages are counted if PerPackage is set
+func (ls *ListSetters) Summarize() Summary {
+	var s Summary
+	results := ls.GetResults()
+	for _, p := range ls.Packages {
+		results = append(results, p.Setters.GetResults()...)
+	}
+	for _, r := range results {
+		s.Setters++
+		if r.Type == ArraySetterType {
+			s.ArraySetters++
+		} else {
+			s.ScalarSetters++
+		}
+		s.Occurrences += r.Count
+	}
+	return s
+}
+
+// UsageHistogram buckets the setters listed by GetResults by their count,
+// the setters of all the packages are counted if PerPackage is set
+func (ls *ListSetters) UsageHistogram() []HistogramBucket {
+	out := make([]HistogramBucket, len(histogramBuckets))
+	for i, b := range histogramBuckets {
+		out[i].Label = b.label
+	}
+	results := ls.GetResults()
+	for _, p := range ls.Packages {
+		results = append(results, p.Setters.GetResults()...)
+	}
+	for _, r := range results {
+		for i := len(histogramBuckets) - 1; i >= 0; i-- {
+			if r.Count >= histogramBuckets[i].min {
+				out[i].Setters++
+				break
+			}
+		}
+	}
+	return out
+}
+
+// matchesName returns true if the setter name matches the NamePattern
+func (ls *ListSetters) matchesName(name string) bool {
+	return ls.nameRegex == nil || ls.nameRegex.MatchString(name)
+}
+
+// matchesNamespace returns true if the resource node is in the Namespace,
+// or Namespace is empty
+func (ls *ListSetters) matchesNamespace(node *yaml.RNode) bool {
+	return ls.Namespace == "" || node.GetNamespace() == ls.Namespace
+}
+
+// matchesKind returns true if the resource node matches any of the Kinds selectors
+func (ls *ListSetters) matchesKind(node *yaml.RNode) bool {
+	if len(ls.Kinds) == 0 {
+		return true
+	}
+	for _, selector := range ls.Kinds {
+		kind := selector
+		apiVersion := ""
+		if i := strings.LastIndex(selector, "/"); i >= 0 {
+			apiVersion, kind = selector[:i], selector[i+1:]
+		}
+		if node.GetKind() == kind && (apiVersion == "" || node.GetApiVersion() == apiVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFile returns true if the resource node is in
+// one of the ChangedFiles or if ChangedFiles is empty
+func (ls *ListSetters) matchesFile(node *yaml.RNode) bool {
+	if len(ls.ChangedFiles) == 0 {
+		return true
+	}
+	np := path.Clean(nodePath(node))
+	for _, f := range ls.ChangedFiles {
+		if np == f {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedFiles returns the sorted file paths from the per file counts
+func sortedFiles(files map[string]int) []string {
+	var out []string
+	for f := range files {
+		if f == "" {
+			// snippets aren't attributed to a file
+			continue
+		}
+		out = append(out, f)
 	}
+	sort.Strings(out)
+	return out
+}
 
-	// discover setters from config
-	for i := range nodes {
-		filePath, _, err := kioutil.GetFileAnnotations(nodes[i])
+// Filter implements list as a yaml.Filter
+func (ls *ListSetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	return ls.FilterWithContext(context.Background(), nodes)
+}
+
+// FilterWithContext implements Filter, the discovery is aborted and the error
+// of ctx is returned as soon as ctx is done, which is checked between nodes
+func (ls *ListSetters) FilterWithContext(ctx context.Context, nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if err := ls.compileNamePattern(); err != nil {
+		return nodes, err
+	}
+	if err := ls.compileNameRegex(); err != nil {
+		return nodes, err
+	}
+	nodes, err := ls.withoutInventory(nodes)
+	if err != nil {
+		return nodes, err
+	}
+	ls.Stats = Stats{KptfileFound: hasKptfile(nodes)}
+	// the errorOn categories are checked against all the warnings
+	defer func() { ls.Warnings = ls.cappedWarnings() }()
+	if ls.PerPackage {
+		if err := ls.filterPackages(ctx, nodes); err != nil {
+			return nodes, err
+		}
+		out, err := ls.appendInventory(nodes)
 		if err != nil {
 			return nodes, err
 		}
-		ls.filePath = filePath
-		err = accept(ls, nodes[i])
+		if err := ls.strictError(); err != nil {
+			return out, err
+		}
+		return out, ls.errorOnError()
+	}
+
+	if ls.IncludeKptfile {
+		// attempt to discover setters from Kptfile
+		kfSetters, sources, err := findKptfileSetters(nodes)
+		if err != nil {
+			var discoveryWarning *WarnSetterDiscovery
+			if ok := goerrors.As(err, &discoveryWarning); ok {
+				ls.Warnings = append(ls.Warnings, discoveryWarning)
+			} else {
+				return nodes, err
+			}
+		}
+		upSetters, upSources, err := ls.findUpstreamSetters(nodes)
 		if err != nil {
-			return nil, errors.Wrap(err)
+			return nodes, err
+		}
+		if len(upSetters) > 0 {
+			// setters declared downstream override the inherited ones
+			kfSetters = mergeSetters(upSetters, kfSetters)
+			sources = mergeSetters(upSources, sources)
+		}
+		if kfSetters != nil {
+			ls.kfSetters = kfSetters
+			ls.addKptfileSetters(kfSetters, sources)
+		}
+		if kfNode, err := findKptfileNode(nodes); err == nil {
+			ls.kfPath = nodePath(kfNode)
 		}
 	}
-	return nodes, nil
+
+	// discover setters from config, the resources wrapped in Lists are visited separately
+	resources, err := ls.collectAll(ctx, expandLists(nodes))
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if res != nil {
+			ls.addFields(res)
+		}
+	}
+	ls.checkConflictingValues()
+	ls.checkSetterKinds()
+	ls.checkSetterCollisions()
+	ls.checkInconsistentValues()
+	ls.checkUnresolvedUpstream()
+	ls.checkSetterNames()
+	ls.checkDependencies()
+	if ls.Verify {
+		ls.checkRoundTrip()
+	}
+	if ls.DetectUntagged {
+		if err := ls.findUntagged(expandLists(nodes)); err != nil {
+			return nil, err
+		}
+	}
+	nodes, err = ls.appendInventory(nodes)
+	if err != nil {
+		return nodes, err
+	}
+	if err := ls.strictError(); err != nil {
+		return nodes, err
+	}
+	return nodes, ls.errorOnError()
+}
+
+// strictError returns an error listing the Warnings in strict mode
+func (ls *ListSetters) strictError() error {
+	if !ls.Strict || len(ls.Warnings) == 0 {
+		return nil
+	}
+	warnings := ls.cappedWarnings()
+	msgs := make([]string, len(warnings))
+	for i := range warnings {
+		msgs[i] = warnings[i].Error()
+	}
+	return errors.Errorf("found setter discovery warnings in strict mode: %s", strings.Join(msgs, "; "))
+}
+
+// cappedWarnings returns the first MaxWarnings of the Warnings followed by
+// a warning counting the others, all the Warnings if they don't exceed it
+func (ls *ListSetters) cappedWarnings() []*WarnSetterDiscovery {
+	if ls.MaxWarnings <= 0 || len(ls.Warnings) <= ls.MaxWarnings {
+		return ls.Warnings
+	}
+	out := append([]*WarnSetterDiscovery{}, ls.Warnings[:ls.MaxWarnings]...)
+	return append(out, &WarnSetterDiscovery{Reason: TruncatedWarnings, message: fmt.Sprintf(
+		"and %d more warnings, not listed as %s is %d", len(ls.Warnings)-ls.MaxWarnings, MaxWarningsKey, ls.MaxWarnings)})
+}
+
+// DiscoverSetters discovers the setters parameterizing the fields of a single
+// resource node without reading the Kptfile. The discovered setters are added
+// to the setters of ls and the results of all the setters are returned.
+func (ls *ListSetters) DiscoverSetters(node *yaml.RNode) ([]*Result, error) {
+	if ls.nameRegex == nil {
+		if err := ls.compileNamePattern(); err != nil {
+			return nil, err
+		}
+	}
+	if err := ls.discoverSetters(node); err != nil {
+		return nil, err
+	}
+	return ls.GetResults(), nil
+}
+
+// discoverSetters implements DiscoverSetters without computing the results
+func (ls *ListSetters) discoverSetters(node *yaml.RNode) error {
+	res, err := ls.collectFields(node)
+	if err != nil {
+		return err
+	}
+	ls.addFields(res)
+	return nil
+}
+
+// offsetAnnotation records the line:column offsets of the resources of the
+// items of a List, whose fields are relative to the List rather than to them
+const offsetAnnotation = "internal.config.kubernetes.io/list-setters-offset"
+
+// offsets returns the number of lines before the resource node in the input
+// and of columns it's indented by, i.e. the position of its first field
+// minus one
+func offsets(node *yaml.RNode) (line, column int) {
+	if v, ok := node.GetAnnotations()[offsetAnnotation]; ok {
+		if _, err := fmt.Sscanf(v, "%d:%d", &line, &column); err == nil {
+			return line, column
+		}
+	}
+	if node.YNode().Line == 0 {
+		return 0, 0
+	}
+	return node.YNode().Line - 1, node.YNode().Column - 1
+}
+
+// resourceID returns the identity of the resource node
+func resourceID(node *yaml.RNode) string {
+	return fmt.Sprintf("%s/%s/%s/%s", node.GetApiVersion(), node.GetKind(), node.GetNamespace(), node.GetName())
 }
 
 /*
 visitMapping takes input mapping node, and performs following steps
-checks if the key node of the input mapping node has line comment with SetterCommentIdentifier
+checks if the key or value node of the input mapping node has line comment with SetterComment
 checks if the value node is of sequence node type
-if yes to both, adds to list of ArraySetters or updates count of corresponding ArraySetter
+if yes to both, collects the field to be added to ArraySetters
 */
-func (ls *ListSetters) visitMapping(object *yaml.RNode, path string) error {
+func (c *fieldCollector) visitMapping(object *yaml.RNode, path string, res *resource) error {
 	return object.VisitFields(func(node *yaml.MapNode) error {
 		if node == nil || node.Key.IsNil() || node.Value.IsNil() {
 			// don't do IsNilOrEmpty check as empty sequences are allowed
 			return nil
 		}
 
+		if node.Value.YNode().Kind == yaml.MappingNode {
+			// the setter comment on the key of a mapping parameterizes the key
+			// e.g. `us-east1: # kpt-set: ${region}`
+			setterPattern := extractSetterPattern(node.Key.YNode().LineComment, c.setterComment, c.syntax)
+			if setterPattern != "" {
+				c.trace(res, path+"."+node.Key.YNode().Value, "found setter pattern %q on the key of a mapping, the key is parameterized", setterPattern)
+				c.out.fields = append(c.out.fields, setterField{node: node.Key, pattern: setterPattern,
+					valueType: KeySetterType, path: path + "." + node.Key.YNode().Value})
+			} else {
+				c.traceComment(res, path+"."+node.Key.YNode().Value, node.Key.YNode().LineComment)
+			}
+			return nil
+		}
+
+		if isBlockScalar(node.Value) && node.Value.YNode().LineComment == "" {
+			// the setter comment of a block scalar may be on the key when the
+			// block starts on the next line e.g. `config: # kpt-set: ${cfg}`
+			setterPattern := extractSetterPattern(node.Key.YNode().LineComment, c.setterComment, c.syntax)
+			if setterPattern == "" {
+				setterPattern = c.headPattern(res, path, node)
+			}
+			if setterPattern != "" {
+				c.trace(res, path+"."+node.Key.YNode().Value, "found setter pattern %q on the key of a block scalar", setterPattern)
+				valueType := strings.TrimPrefix(node.Value.YNode().Tag, "!!")
+				c.out.fields = append(c.out.fields, setterField{node: node.Value, pattern: setterPattern,
+					valueType: valueType, path: path + "." + node.Key.YNode().Value})
+			} else {
+				c.traceComment(res, path+"."+node.Key.YNode().Value, node.Key.YNode().LineComment)
+			}
+			return nil
+		}
+
 		// return if it is not a sequence node
 		if node.Value.YNode().Kind != yaml.SequenceNode {
+			if setterPattern := extractSetterPattern(node.Key.YNode().LineComment, c.setterComment, c.syntax); setterPattern != "" {
+				if node.Value.YNode().Tag == yaml.NodeTagNull && node.Value.YNode().Value == "" {
+					// the setter comment of an empty null value e.g.
+					// `field: # kpt-set: ${x}` can only follow the key
+					c.trace(res, path+"."+node.Key.YNode().Value, "found setter pattern %q on the key of a null field", setterPattern)
+					c.out.fields = append(c.out.fields, setterField{node: node.Value, pattern: setterPattern,
+						valueType: NullValueType, path: path + "." + node.Key.YNode().Value})
+					return nil
+				}
+				c.trace(res, path+"."+node.Key.YNode().Value,
+					"setter comment on the key of a scalar field is ignored, it must follow the value")
+				return nil
+			}
+			if node.Value.YNode().Kind != yaml.ScalarNode || fieldSetterPattern(node.Value.YNode(), c.setterComment, c.syntax) != "" {
+				// the setter comment of the value is collected by visitScalar
+				return nil
+			}
+			if setterPattern := c.headPattern(res, path, node); setterPattern != "" {
+				valueType := strings.TrimPrefix(node.Value.YNode().Tag, "!!")
+				c.out.fields = append(c.out.fields, setterField{node: node.Value, pattern: setterPattern,
+					valueType: valueType, path: path + "." + node.Key.YNode().Value})
+			}
 			return nil
 		}
 
@@ -267,43 +1477,68 @@ func (ls *ListSetters) visitMapping(object *yaml.RNode, path string) error {
 			return errors.Wrap(err)
 		}
 
-		// extracts the values in sequence node to an array
-		var nodeValues []string
-		for _, values := range elements {
-			nodeValues = append(nodeValues, values.YNode().Value)
+		// the setter comment is on the key node for block style sequences but
+		// it could be on either key or value node for flow style sequences
+		// e.g. `images: [a, b] # kpt-set: ${images}` has it on the value node
+		setterPattern := extractSetterPattern(node.Key.YNode().LineComment, c.setterComment, c.syntax)
+		if setterPattern == "" {
+			setterPattern = extractSetterPattern(node.Value.YNode().LineComment, c.setterComment, c.syntax)
 		}
-		sort.Strings(nodeValues)
-
-		linecomment := node.Key.YNode().LineComment
-		if node.Value.YNode().Style == yaml.FlowStyle {
-			linecomment = node.Value.YNode().LineComment
+		if setterPattern == "" {
+			setterPattern = c.headPattern(res, path, node)
 		}
-
-		// perform a direct set of the field if it matches
-		setterPattern := extractSetterPattern(linecomment)
 		if setterPattern == "" {
 			// the node is not tagged with setter pattern
+			c.traceComment(res, path+"."+node.Key.YNode().Value, node.Key.YNode().LineComment)
+			c.traceComment(res, path+"."+node.Key.YNode().Value, node.Value.YNode().LineComment)
 			return nil
 		}
-
-		// add setter to discovered array setters or update count of existing setter
-		setterName := clean(setterPattern)
-		_, ok := ls.ArraySetters[setterName]
-		if ok {
-			ls.ArraySetters[setterName].Count += 1
-		} else {
-			ls.ArraySetters[setterName] = &ArraySetter{Name: setterName, Values: nodeValues, Count: 1}
+		style := "block"
+		if node.Value.YNode().Style&yaml.FlowStyle != 0 {
+			style = "flow"
 		}
+		c.trace(res, path+"."+node.Key.YNode().Value, "found array setter pattern %q on a %s style sequence", setterPattern, style)
+		c.out.fields = append(c.out.fields, setterField{node: node.Key, pattern: setterPattern,
+			path: path + "." + node.Key.YNode().Value, array: true, elements: elements})
 		return nil
 	})
 }
 
+// addArraySetter adds the array setter parameterizing the
+// field to the discovered array setters
+func (ls *ListSetters) addArraySetter(res *resource, f setterField) {
+	// extracts the values in sequence node to an array in document order,
+	// they are ordered for reporting by GetResults
+	var nodeValues []string
+	for _, values := range f.elements {
+		nodeValues = append(nodeValues, ls.elementValue(values))
+	}
+
+	// add setter to discovered array setters or update count of existing setter
+	setterName, _ := ls.syntax().ParseReference(f.pattern)
+	ls.trace(res, f.path, "array setter %q resolved [%s]", setterName, strings.Join(nodeValues, ", "))
+	_, ok := ls.ArraySetters[setterName]
+	if ok {
+		ls.ArraySetters[setterName].Count += 1
+	} else {
+		ls.ArraySetters[setterName] = &ArraySetter{Name: setterName, Values: nodeValues, Count: 1, Files: make(map[string]int), Resources: make(map[string]int)}
+	}
+	ls.ArraySetters[setterName].Files[res.filePath]++
+	ls.ArraySetters[setterName].Resources[res.id]++
+	ls.ArraySetters[setterName].Locations = append(ls.ArraySetters[setterName].Locations, ls.location(res, f.node, f.path))
+	ls.checkArrayDrift(res, setterName, nodeValues)
+	ls.checkConstraint(res, setterName, nodeValues)
+	if ls.DryRun {
+		ls.addArrayChange(res, setterName, f.elements, f.path)
+	}
+}
+
 /*
 visitScalar accepts the input scalar node and performs following steps,
-checks if the line comment of input scalar node has prefix SetterCommentIdentifier
-adds to list of ScalarSetters or updates count of corresponding ScalarSetter
+checks if the line comment of input scalar node has prefix SetterComment
+collects the field to be added to ScalarSetters
 */
-func (ls *ListSetters) visitScalar(object *yaml.RNode, path string) error {
+func (c *fieldCollector) visitScalar(object *yaml.RNode, path string, depth int, res *resource) error {
 	if object.IsNil() {
 		return nil
 	}
@@ -313,17 +1548,40 @@ func (ls *ListSetters) visitScalar(object *yaml.RNode, path string) error {
 		return nil
 	}
 
+	if c.embeddedYAML || isInlinePatch(res, path) {
+		if err := c.visitEmbedded(object, path, depth, res); err != nil {
+			return err
+		}
+	}
+
 	linecomment := object.YNode().LineComment
 
 	// perform a direct set of the field if it matches
-	setterPattern := extractSetterPattern(linecomment)
+	setterPattern := extractSetterPattern(linecomment, c.setterComment, c.syntax)
+	if setterPattern == "" && object.YNode().HeadComment != "" {
+		// e.g. a sequence element with the setter comment on the line above it
+		setterPattern = extractHeadSetterPattern(object.YNode().HeadComment, c.setterComment, c.syntax)
+		if setterPattern != "" {
+			c.trace(res, path, "found setter pattern %q in the head comment", setterPattern)
+		}
+	}
 	if setterPattern == "" {
 		// the node is not tagged with setter pattern
+		c.traceComment(res, path, linecomment)
 		return nil
 	}
-	currentSetterValues := currentSetterValues(setterPattern, object.YNode().Value)
+	c.trace(res, path, "found setter pattern %q", setterPattern)
 	// data type for the current value
 	valueType := strings.TrimPrefix(object.YNode().Tag, "!!")
+	c.out.fields = append(c.out.fields, setterField{node: object, pattern: setterPattern, valueType: valueType, path: path})
+	return nil
+}
+
+// addScalarSetters adds the setters in setterPattern parameterizing the
+// scalar node at path to the discovered scalar setters
+func (ls *ListSetters) addScalarSetters(res *resource, object *yaml.RNode, setterPattern, valueType, path string) {
+	currentSetterValues := ls.resolveSetterValues(setterPattern, scalarValue(object))
+	ls.traceResolved(res, path, setterPattern, scalarValue(object), currentSetterValues)
 
 	// add setters to discovered scalar setters or update count of existing setter
 	for setterName, setterValue := range currentSetterValues {
@@ -336,84 +1594,653 @@ func (ls *ListSetters) visitScalar(object *yaml.RNode, path string) error {
 			}
 			ls.ScalarSetters[setterName].Count++
 		} else {
-			ls.ScalarSetters[setterName] = &ScalarSetter{Name: setterName, Value: setterValue, Type: valueType, Count: 1}
+			ls.ScalarSetters[setterName] = &ScalarSetter{Name: setterName, Value: setterValue, Type: valueType, ValueType: inferValueType(setterValue), Count: 1, Files: make(map[string]int), Resources: make(map[string]int)}
+		}
+		ls.ScalarSetters[setterName].Files[res.filePath]++
+		ls.ScalarSetters[setterName].Resources[res.id]++
+		ls.ScalarSetters[setterName].Locations = append(ls.ScalarSetters[setterName].Locations, ls.location(res, object, path))
+		ls.ScalarSetters[setterName].addDistinctValue(setterValue, res.filePath)
+		ls.ScalarSetters[setterName].addFieldValue(documentKey{res.filePath, res.ref.String()}, strings.TrimPrefix(path, "."), setterValue)
+		ls.ScalarSetters[setterName].setAppliedValue(setterValue, ls.isDeclared(setterName))
+		if setterValue == "" {
+			ls.ScalarSetters[setterName].Empty = true
 		}
+	}
+	for _, loc := range ls.syntax().FindReferences(setterPattern) {
+		if name, def := ls.syntax().ParseReference(setterPattern[loc[0]:loc[1]]); def != "" && ls.ScalarSetters[name] != nil {
+			ls.ScalarSetters[name].Default = def
+		}
+	}
+	if ls.DryRun {
+		ls.addScalarChange(res, setterPattern, currentSetterValues, object, path)
+	}
+}
 
+// isBlockScalar returns true if the node is a literal (|) or folded (>) block scalar
+func isBlockScalar(node *yaml.RNode) bool {
+	return node.YNode().Kind == yaml.ScalarNode && node.YNode().Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+}
+
+// scalarValue returns the value of the scalar node to match setter patterns
+// against. The line breaks the block scalars end with are trimmed, line
+// breaks within literal block scalars are kept and folded block scalars
+// are already folded into a single line by the parser. Null values, i.e.
+// ~, Null or no value, are normalized to null.
+func scalarValue(node *yaml.RNode) string {
+	if node.YNode().Tag == yaml.NodeTagNull {
+		return "null"
+	}
+	if isBlockScalar(node) {
+		return strings.TrimRight(node.YNode().Value, "\n")
+	}
+	return node.YNode().Value
+}
+
+// inferValueType returns the type which value resolves to as a plain YAML
+// scalar, e.g. int for 3 even if the field is the quoted string "3"
+func inferValueType(value string) string {
+	n := yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	switch n.ShortTag() {
+	case yaml.NodeTagInt:
+		return IntValueType
+	case yaml.NodeTagBool:
+		return BoolValueType
+	case yaml.NodeTagFloat:
+		return FloatValueType
+	case yaml.NodeTagNull:
+		if value != "" {
+			// empty values are reported as Empty strings rather than null
+			return NullValueType
+		}
+	}
+	return StringValueType
+}
+
+// visitEmbedded discovers the setters in the YAML documents embedded in the
+// string value of the scalar node. Values which don't contain the setter
+// comment or don't parse as a mapping or sequence are skipped, malformed
+// documents of multi-document values are skipped with a warning.
+func (c *fieldCollector) visitEmbedded(object *yaml.RNode, path string, depth int, res *resource) error {
+	value := object.YNode().Value
+	if object.YNode().Tag != yaml.NodeTagString || !strings.Contains(value, commentKeyword(c.setterComment)) {
+		return nil
+	}
+	// the positions of the embedded documents are relative to them
+	embeddedRes := *res
+	embeddedRes.lineOffset, embeddedRes.columnOffset = 0, 0
+	docs := documentSeparator.Split(value, -1)
+	for i, doc := range docs {
+		embedded, err := yaml.Parse(doc)
+		if err != nil {
+			if len(docs) > 1 {
+				c.out.warnings = append(c.out.warnings, &WarnSetterDiscovery{Reason: InvalidEmbeddedDocument, message: fmt.Sprintf(
+					"unable to parse embedded document %d of %s in %s: %v", i, strings.TrimPrefix(path, "."), res.filePath, err),
+					File: res.filePath, Path: strings.TrimPrefix(path, ".")})
+			}
+			// arbitrary text is not expected to be valid YAML
+			continue
+		}
+		switch embedded.YNode().Kind {
+		case yaml.MappingNode, yaml.SequenceNode:
+			if err := acceptImpl(c, embedded, path, depth, &embeddedRes); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// documentSeparator matches the separators of YAML documents
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// setAppliedValue records the value of the first field parameterized by the setter
+func (s *ScalarSetter) setAppliedValue(value string, declared bool) {
+	if s.AppliedValue != "" {
+		return
+	}
+	s.AppliedValue = value
+	s.Overridden = declared && s.AppliedValue != s.DeclaredValue
+}
+
+// isDeclared returns true if the setter is declared in the Kptfile
+func (ls *ListSetters) isDeclared(name string) bool {
+	_, ok := ls.kfSetters[name]
+	return ok
+}
+
+// addDistinctValue records that value of the setter is used in filePath
+func (s *ScalarSetter) addDistinctValue(value, filePath string) {
+	if s.DistinctValues == nil {
+		s.DistinctValues = make(map[string][]string)
+	}
+	for _, f := range s.DistinctValues[value] {
+		if f == filePath {
+			return
+		}
+	}
+	s.DistinctValues[value] = append(s.DistinctValues[value], filePath)
+}
+
+// addFieldValue records the value of a field parameterized by the setter in the document
+func (s *ScalarSetter) addFieldValue(doc documentKey, path, value string) {
+	if s.documentFields == nil {
+		s.documentFields = make(map[documentKey][]FieldValue)
+	}
+	s.documentFields[doc] = append(s.documentFields[doc], FieldValue{Path: path, Value: value})
+}
+
+// checkInconsistentValues records the Inconsistencies of each scalar setter
+// parameterizing fields with different values in the same document and adds
+// a warning for each of them, as only one of the values can be applied
+func (ls *ListSetters) checkInconsistentValues() {
+	var names []string
+	for name := range ls.ScalarSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := ls.ScalarSetters[name]
+		s.Inconsistencies = nil
+		for doc, fields := range s.documentFields {
+			for _, f := range fields[1:] {
+				if f.Value != fields[0].Value {
+					s.Inconsistencies = append(s.Inconsistencies, Inconsistency{File: doc.file, Resource: doc.resource, Fields: fields})
+					break
+				}
+			}
+		}
+		sort.Slice(s.Inconsistencies, func(i, j int) bool {
+			if s.Inconsistencies[i].File != s.Inconsistencies[j].File {
+				return s.Inconsistencies[i].File < s.Inconsistencies[j].File
+			}
+			return s.Inconsistencies[i].Resource < s.Inconsistencies[j].Resource
+		})
+		for _, inc := range s.Inconsistencies {
+			values := make([]string, len(inc.Fields))
+			for i, f := range inc.Fields {
+				values[i] = fmt.Sprintf("%s=%q", f.Path, f.Value)
+			}
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: InconsistentValues, message: fmt.Sprintf(
+				"setter %q has inconsistent values in %s of %s: %s", name, inc.Resource, inc.File, strings.Join(values, ", ")), File: inc.File})
+		}
+	}
+}
+
+// checkSetterNames adds a warning for each listed setter
+// whose name doesn't match the NameRegex
+func (ls *ListSetters) checkSetterNames() {
+	if ls.nameValidator == nil {
+		return
+	}
+	for _, r := range ls.GetResults() {
+		if !ls.nameValidator.MatchString(r.Name) {
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: InvalidName, message: fmt.Sprintf(
+				"setter name %q doesn't match %s %q", r.Name, NameRegexKey, ls.NameRegex)})
+		}
+	}
+}
+
+// checkConflictingValues adds a warning for each scalar setter
+// parameterizing fields with different values
+func (ls *ListSetters) checkConflictingValues() {
+	var names []string
+	for name, s := range ls.ScalarSetters {
+		if len(s.DistinctValues) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ls.Warnings = append(ls.Warnings, conflictWarning(ls.ScalarSetters[name]))
+	}
+}
+
+// conflictWarning returns the warning listing the distinct values of the setter
+func conflictWarning(s *ScalarSetter) *WarnSetterDiscovery {
+	var values []string
+	for v := range s.DistinctValues {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	conflicts := make([]string, len(values))
+	for i, v := range values {
+		conflicts[i] = fmt.Sprintf("%q in [%s]", v, strings.Join(s.DistinctValues[v], ", "))
+	}
+	return &WarnSetterDiscovery{Reason: ConflictingValues, message: fmt.Sprintf("setter %q has conflicting values %s", s.Name, strings.Join(conflicts, ", "))}
+}
+
+// dependencies returns the sorted names of the setters referenced in the
+// value, or the values of an array setter, e.g. project for the value
+// gcr.io/${project}/nginx when setter values are substituted in layers.
+// The setter itself isn't a dependency, its value is then an unresolved
+// placeholder e.g. foo-${bar} which is reported by the resolve mode.
+func (ls *ListSetters) dependencies(r *Result) []string {
+	values := r.Values
+	if r.Type != ArraySetterType {
+		values = []string{r.Value}
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		for _, loc := range ls.syntax().FindReferences(v) {
+			name, _ := ls.syntax().ParseReference(v[loc[0]:loc[1]])
+			if name != r.Name && !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// checkDependencies adds a warning for each setter whose value references
+// other setters, as apply-setters only substitutes them in another pass,
+// which is often misdiagnosed as the setter not being applied
+func (ls *ListSetters) checkDependencies() {
+	for _, r := range ls.GetResults() {
+		if len(r.DependsOn) == 0 {
+			continue
+		}
+		ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: UnresolvedDependency, message: fmt.Sprintf(
+			"setter %q depends on setters [%s] referenced in its value, which are only substituted by another apply-setters pass",
+			r.Name, strings.Join(r.DependsOn, ", "))})
+	}
+}
+
+// checkSetterKinds adds a warning for each setter declared in the Kptfile as
+// a scalar which parameterizes array fields and vice versa, e.g. a list
+// declared as the comma separated string "a,b" instead of "[a, b]"
+func (ls *ListSetters) checkSetterKinds() {
+	var names []string
+	for name := range ls.kfSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := getArraySetterValues(ls.kfSetters[name]); err == nil {
+			if s, ok := ls.ScalarSetters[name]; ok && s.Count > 0 {
+				ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: KindMismatch, message: fmt.Sprintf(
+					"setter %q is declared as an array in the Kptfile but parameterizes scalar fields in [%s]",
+					name, strings.Join(sortedFiles(s.Files), ", "))})
+			}
+		} else if s, ok := ls.ArraySetters[name]; ok && s.Count > 0 {
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: KindMismatch, message: fmt.Sprintf(
+				"setter %q is declared as a scalar in the Kptfile but parameterizes array fields in [%s], "+
+					"array setter values must be declared as YAML lists e.g. \"[a, b]\"",
+				name, strings.Join(sortedFiles(s.Files), ", "))})
+		}
+	}
+}
+
+// checkSetterCollisions adds a warning for each setter name which
+// parameterizes both scalar and array fields, which apply-setters can't
+// set consistently. Setters declared in the Kptfile are reported by
+// checkSetterKinds if they are only used with the other kind.
+func (ls *ListSetters) checkSetterCollisions() {
+	var names []string
+	for name, s := range ls.ScalarSetters {
+		if a, ok := ls.ArraySetters[name]; ok && s.Count > 0 && a.Count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: KindMismatch, message: fmt.Sprintf(
+			"setter %q parameterizes both scalar fields in [%s] and array fields in [%s], which apply-setters can't set consistently",
+			name, strings.Join(sortedFiles(ls.ScalarSetters[name].Files), ", "), strings.Join(sortedFiles(ls.ArraySetters[name].Files), ", "))})
+	}
+}
+
+// checkArrayDrift adds a warning if the values of the array setter discovered in
+// the resource differ from the values declared in the Kptfile
+func (ls *ListSetters) checkArrayDrift(res *resource, name string, values []string) {
+	declared, ok := ls.kfSetters[name]
+	if !ok {
+		return
+	}
+	declaredValues, err := getArraySetterFieldValues(declared, ls.ArrayValueField)
+	if err != nil {
+		return
+	}
+	added := difference(values, declaredValues)
+	removed := difference(declaredValues, values)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: ArrayValuesMismatch, message: fmt.Sprintf(
+		"array setter %q in %s doesn't match the values declared in the Kptfile, added: [%s], removed: [%s]",
+		name, res.filePath, strings.Join(added, ", "), strings.Join(removed, ", ")), File: res.filePath})
+}
+
+// difference returns the sorted distinct elements of a which are not in b
+func difference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+	}
+	var out []string
+	for _, v := range a {
+		if !inB[v] {
+			out = append(out, v)
+			inB[v] = true
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// location returns the location of the node at path in the file of the resource,
+// the line and column are relative to the first field of the resource
+func (ls *ListSetters) location(res *resource, node *yaml.RNode, path string) Location {
+	loc := Location{File: res.filePath, Line: node.YNode().Line - res.lineOffset, Column: node.YNode().Column - res.columnOffset,
+		Document: res.document, Path: strings.TrimPrefix(path, ".")}
+	if ls.IncludeResource {
+		ref := res.ref
+		loc.Resource = &ref
+	}
+	if ls.ProvenanceAnnotation != "" {
+		loc.Provenance = res.annotations[ls.ProvenanceAnnotation]
+	}
+	return loc
+}
+
 // extractSetterPattern extracts the setter pattern from the line comment of the
-// yaml RNode. If the the line comment doesn't contain SetterCommentIdentifier
-// prefix, then it returns empty string
-func extractSetterPattern(lineComment string) string {
-	if !strings.HasPrefix(lineComment, SetterCommentIdentifier) {
+// yaml RNode. If the the line comment doesn't contain identifier prefix, then it
+// returns empty string.
+// The pattern ends with the last setter reference of the syntax e.g. ${...} and
+// the non-whitespace characters directly following it, any text after them is a
+// trailing description which is dropped e.g. "${foo}-bar (legacy)" returns
+// "${foo}-bar". Text between the references is kept e.g. "${first} ${last}" is
+// a single pattern. DollarBraceSyntax is used if syntax is nil. An empty string
+// is returned if the setter comment has the IgnoreDirective.
+func extractSetterPattern(lineComment, identifier string, syntax SetterSyntax) string {
+	pattern, ok := trimSetterComment(lineComment, identifier)
+	if !ok || ignoreDirective.MatchString(pattern) {
+		return ""
+	}
+	locs := syntaxOrDefault(syntax).FindReferences(pattern)
+	if len(locs) == 0 {
+		return pattern
+	}
+	end := locs[len(locs)-1][1]
+	if i := strings.IndexFunc(pattern[end:], unicode.IsSpace); i >= 0 {
+		return pattern[:end+i]
+	}
+	return pattern
+}
+
+// trimSetterComment returns the rest of the comment following the setter
+// comment identifier, and false if the comment doesn't start with it. The
+// whitespace of the identifier is matched loosely as users commonly write
+// the comment with tabs, extra spaces or without spaces e.g. "#kpt-set:${foo}"
+// and "#  kpt-set:\t${foo}" both match "# kpt-set: ". An identifier ending
+// with a letter or digit must be followed by whitespace in the comment, so
+// that e.g. "# set " doesn't match "# setter".
+func trimSetterComment(comment, identifier string) (string, bool) {
+	if identifier == "" {
+		identifier = SetterCommentIdentifier
+	}
+	rest := comment
+	fields := strings.Fields(identifier)
+	for _, field := range fields {
+		rest = strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(rest, field) {
+			return "", false
+		}
+		rest = rest[len(field):]
+	}
+	if len(fields) == 0 {
+		return "", false
+	}
+	last := []rune(fields[len(fields)-1])
+	end := last[len(last)-1]
+	if rest != "" && (unicode.IsLetter(end) || unicode.IsDigit(end)) && !unicode.IsSpace([]rune(rest)[0]) {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// commentKeyword returns the last word of the setter comment identifier e.g.
+// "kpt-set:" for "# kpt-set: ", which any setter comment contains regardless
+// of its whitespace
+func commentKeyword(identifier string) string {
+	if identifier == "" {
+		identifier = SetterCommentIdentifier
+	}
+	fields := strings.Fields(identifier)
+	if len(fields) == 0 {
 		return ""
 	}
-	return strings.TrimSpace(strings.TrimPrefix(lineComment, SetterCommentIdentifier))
+	return fields[len(fields)-1]
+}
+
+// extractHeadSetterPattern extracts the setter pattern from the head comment of a
+// field, which some YAML writers emit instead of the line comment, or fold the
+// setter comment into along with other comments e.g.
+//
+//	# the name of the app
+//	# kpt-set: ${app}
+//	name: my-app
+//
+// Each line of the head comment is checked for the setter comment identifier,
+// the last one is used as it's the closest to the field.
+func extractHeadSetterPattern(headComment, identifier string, syntax SetterSyntax) string {
+	lines := strings.Split(headComment, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if pattern := extractSetterPattern(strings.TrimSpace(lines[i]), identifier, syntax); pattern != "" {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// fieldSetterPattern returns the setter pattern of the line comment of
+// the node, or of its head comment if the line comment has none
+func fieldSetterPattern(n *yaml.Node, identifier string, syntax SetterSyntax) string {
+	if pattern := extractSetterPattern(n.LineComment, identifier, syntax); pattern != "" {
+		return pattern
+	}
+	return extractHeadSetterPattern(n.HeadComment, identifier, syntax)
 }
 
 // currentSetterValues takes pattern and value and returns setter names to values
 // derived using pattern matching
 // e.g. pattern = my-app-layer.${stage}.${domain}.${tld}, value = my-app-layer.dev.example.com
 // returns {"stage":"dev", "domain":"example", "tld":"com"}
+// Setters followed by a literal capture the shortest possible value, the last setter
+// captures the rest of the value. Adjacent setters e.g. ${image}${tag} can't be told
+// apart and repeated setters must capture the same value, otherwise an empty map is
+// returned. A setter capturing nothing in a matching value resolves to an empty
+// value e.g. pattern = app${suffix}, value = app returns {"suffix": ""}, while an
+// empty map is returned if the value doesn't match the pattern.
 func currentSetterValues(pattern, value string) map[string]string {
-	res := make(map[string]string)
-	// get all setter names enclosed in ${}
-	// e.g. value: my-app-layer.dev.example.com
-	// pattern: my-app-layer.${stage}.${domain}.${tld}
-	// urs: [${stage}, ${domain}, ${tld}]
-	urs := unresolvedSetters(pattern)
-	// and escape pattern
-	pattern = regexp.QuoteMeta(pattern)
-	// escaped pattern: my-app-layer\.\$\{stage\}\.\$\{domain\}\.\$\{tld\}
-
-	for _, setterName := range urs {
-		// escape setter name
-		// we need to escape the setterName as well to replace it in the escaped pattern string later
-		setterName = regexp.QuoteMeta(setterName)
-		pattern = strings.ReplaceAll(
-			pattern,
-			setterName,
-			`(?P<x>.*)`) // x is just a place holder, it could be any alphanumeric string
-	}
-	// pattern: my-app-layer\.(?P<x>.*)\.(?P<x>.*)\.(?P<x>.*)
-	r, err := regexp.Compile(pattern)
+	res, _ := matchSetterValues(pattern, value, matchOptions{})
+	return res
+}
+
+// matchOptions controls how setter patterns are matched against field values
+type matchOptions struct {
+	// anchored matches the pattern against the whole value
+	anchored bool
+
+	// ignoreCase matches the literal parts of the pattern case-insensitively
+	ignoreCase bool
+
+	// syntax identifies the setter references, DollarBraceSyntax if nil
+	syntax SetterSyntax
+}
+
+// resolveSetterValues derives the setter values from the value of a field
+// parameterized by pattern. A pattern wrapped in quotes, e.g. "${port}" for
+// port: "8080", which doesn't match the value is matched without the quotes,
+// as the quotes only preserve the type of the field and aren't part of its
+// value, so that the setter value doesn't depend on the node style.
+func (ls *ListSetters) resolveSetterValues(pattern, value string) map[string]string {
+	res, ok := ls.resolvePattern(pattern, value)
+	if unquoted := unquotePattern(pattern); !ok && unquoted != pattern {
+		res, _ = ls.resolvePattern(unquoted, value)
+	}
+	return res
+}
+
+// unquotePattern returns the pattern without the single or double
+// quotes wrapping it, the pattern is returned as is if it isn't quoted
+func unquotePattern(pattern string) string {
+	if len(pattern) >= 2 && (pattern[0] == '"' || pattern[0] == '\'') && pattern[len(pattern)-1] == pattern[0] {
+		return pattern[1 : len(pattern)-1]
+	}
+	return pattern
+}
+
+// resolvePattern implements resolveSetterValues for the pattern, it
+// additionally returns false if the value doesn't match the pattern.
+// Setters already discovered in other fields are substituted with their
+// values first so that ambiguous patterns such as ${name}-${suffix}
+// resolve consistently with the other fields
+func (ls *ListSetters) resolvePattern(pattern, value string) (map[string]string, bool) {
+	known := make(map[string]string)
+	syntax := ls.syntax()
+	substituted := replaceReferences(syntax, pattern, func(s string) string {
+		name, _ := syntax.ParseReference(s)
+		setter, ok := ls.ScalarSetters[name]
+		if !ok || setter.Count == 0 || syntax.IsUnresolved(setter.Value) {
+			return s
+		}
+		known[name] = setter.Value
+		return setter.Value
+	})
+	opts := matchOptions{anchored: ls.AnchorPattern, ignoreCase: ls.IgnoreCase, syntax: syntax}
+	if len(known) > 0 {
+		if m := ls.matcher(substituted, opts); m != nil {
+			if res, ok := m.match(value); ok {
+				for k, v := range known {
+					res[k] = v
+				}
+				return res, true
+			}
+		}
+	}
+	if m := ls.matcher(pattern, opts); m != nil {
+		return m.match(value)
+	}
+	return make(map[string]string), false
+}
+
+// matchSetterValues implements currentSetterValues with the given options,
+// it additionally returns false if the value doesn't match the pattern
+func matchSetterValues(pattern, value string, opts matchOptions) (map[string]string, bool) {
+	m := newSetterMatcher(pattern, opts)
+	if m == nil {
+		return make(map[string]string), false
+	}
+	return m.match(value)
+}
+
+// matcherKey identifies a compiled setter pattern
+type matcherKey struct {
+	pattern string
+	opts    matchOptions
+}
+
+// matcher returns the setterMatcher of the pattern, compiled
+// patterns are cached as the same patterns are used by many fields
+func (ls *ListSetters) matcher(pattern string, opts matchOptions) *setterMatcher {
+	key := matcherKey{pattern: pattern, opts: opts}
+	if m, ok := ls.matchers[key]; ok {
+		return m
+	}
+	if ls.matchers == nil {
+		ls.matchers = make(map[matcherKey]*setterMatcher)
+	}
+	m := newSetterMatcher(pattern, opts)
+	ls.matchers[key] = m
+	return m
+}
+
+// setterMatcher derives setter values from field values using a compiled pattern
+type setterMatcher struct {
+	// re has a capture group for each setter in the pattern
+	re *regexp.Regexp
+
+	// names are the setter names of the capture groups
+	names []string
+}
+
+// newSetterMatcher compiles the setter pattern, nil is returned
+// if the setter values can't be derived using the pattern
+func newSetterMatcher(pattern string, opts matchOptions) *setterMatcher {
+	// get the positions of all setter references
+	// e.g. pattern: my-app-layer.${stage}.${domain}.${tld}
+	syntax := syntaxOrDefault(opts.syntax)
+	locs := syntax.FindReferences(pattern)
+	// build the escaped pattern with a capture group for each setter
+	var names []string
+	var re strings.Builder
+	// values of literal block scalars span multiple lines
+	re.WriteString(`(?s)`)
+	if opts.ignoreCase {
+		re.WriteString(`(?i)`)
+	}
+	if opts.anchored {
+		re.WriteString(`^`)
+	}
+	prev := 0
+	for i, loc := range locs {
+		re.WriteString(regexp.QuoteMeta(pattern[prev:loc[0]]))
+		if i+1 < len(locs) && locs[i+1][0] == loc[1] {
+			// adjacent setters are ambiguous
+			return nil
+		}
+		if loc[1] == len(pattern) {
+			re.WriteString(`(.*)`)
+		} else {
+			re.WriteString(`(.*?)`)
+		}
+		name, _ := syntax.ParseReference(pattern[loc[0]:loc[1]])
+		names = append(names, name)
+		prev = loc[1]
+	}
+	re.WriteString(regexp.QuoteMeta(pattern[prev:]))
+	if opts.anchored {
+		re.WriteString(`$`)
+	}
+	// escaped pattern: my-app-layer\.(.*?)\.(.*?)\.(.*)
+	r, err := regexp.Compile(re.String())
 	if err != nil {
-		// just return empty map if values can't be derived from pattern
-		return res
+		return nil
 	}
-	setterValues := r.FindStringSubmatch(value)
+	return &setterMatcher{re: r, names: names}
+}
+
+// match returns the setter values derived from value, it
+// returns false if the value doesn't match the pattern
+func (m *setterMatcher) match(value string) (map[string]string, bool) {
+	res := make(map[string]string)
+	setterValues := m.re.FindStringSubmatch(value)
 	if len(setterValues) == 0 {
-		return res
+		return res, false
 	}
 	// setterValues: [ "my-app-layer.dev.example.com", "dev", "example", "com"]
 	setterValues = setterValues[1:]
 	// setterValues: [ "dev", "example", "com"]
-	if len(urs) != len(setterValues) {
+	if len(m.names) != len(setterValues) {
 		// just return empty map if values can't be derived
-		return res
+		return res, false
 	}
 	for i := range setterValues {
-		if setterValues[i] == "" {
-			// if any of the value is unresolved return empty map
-			// and expect users to provide all values
-			return make(map[string]string)
+		// an empty capture is a legitimately empty value e.g. an optional
+		// suffix, as the value matched the pattern
+		if v, ok := res[m.names[i]]; ok && v != setterValues[i] {
+			// repeated setter resolves to different values
+			return make(map[string]string), false
 		}
-		res[clean(urs[i])] = setterValues[i]
+		res[m.names[i]] = setterValues[i]
 	}
-	return res
-}
-
-// unresolvedSetters returns the list of values enclosed in ${} present within given
-// pattern e.g. pattern = foo-${image}:${tag}-bar return ["${image}", "${tag}"]
-func unresolvedSetters(pattern string) []string {
-	re := regexp.MustCompile(`\$\{([^}]*)\}`)
-	return re.FindAllString(pattern, -1)
+	return res, true
 }
 
-// clean extracts value enclosed in ${}
-func clean(input string) string {
-	input = strings.TrimSpace(input)
-	return strings.TrimSuffix(strings.TrimPrefix(input, "${"), "}")
+// syntax returns the Syntax of the setter references, DollarBraceSyntax if not set
+func (ls *ListSetters) syntax() SetterSyntax {
+	return syntaxOrDefault(ls.Syntax)
 }
@@ -1,19 +1,27 @@
 package listsetters
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 func TestListSettersFilter(t *testing.T) {
 	var tests = []struct {
 		name           string
 		resourceMap    map[string]string
+		fnConfig       string
 		expectedResult []*Result
 		errMsg         string
 		warnings       []*WarnSetterDiscovery
@@ -32,7 +40,7 @@ metadata:
     app: my-app
   name: mungebot`},
 			expectedResult: []*Result{},
-			warnings:       []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+			warnings:       []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
 		},
 		{
 			name: "Scalar Simple",
@@ -57,7 +65,7 @@ metadata:
     app: my-app # kpt-set: ${app}
   name: mungebot
 `},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
 		},
 		{
 			name: "Scalar Simple invalid kf",
@@ -102,8 +110,8 @@ metadata:
   labels:
     app: my-app # kpt-set: ${app}
   name: mungebot`},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}},
-			warnings:       []*WarnSetterDiscovery{{"unable to find apply-setters fn in Kptfile Pipeline.Mutators"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: NoApplySetters, message: "unable to find apply-setters fn in Kptfile Pipeline.Mutators"}},
 		},
 		{
 			name: "Scalar Simple missing kf pipeline",
@@ -122,8 +130,8 @@ metadata:
   labels:
     app: my-app # kpt-set: ${app}
   name: mungebot`},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}},
-			warnings:       []*WarnSetterDiscovery{{"unable to find Pipeline declaration in Kptfile"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: NoPipeline, message: "unable to find Pipeline declaration in Kptfile"}},
 		},
 		{
 			name: "Scalar Simple no apply-setter fnConfig",
@@ -145,8 +153,8 @@ metadata:
   labels:
     app: my-app # kpt-set: ${app}
   name: mungebot`},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}},
-			warnings:       []*WarnSetterDiscovery{{"unable to find ConfigMap or ConfigPath fnConfig for apply-setters"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: NoFunctionConfig, message: "unable to find ConfigMap or ConfigPath fnConfig for apply-setters"}},
 		},
 		{
 			name: "Scalar Simple missing apply-setter configPath file",
@@ -169,11 +177,11 @@ metadata:
   labels:
     app: my-app # kpt-set: ${app}
   name: mungebot`},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}},
-			warnings:       []*WarnSetterDiscovery{{"file setters.yaml doesn't exist, please ensure the file specified in \"configPath\" exists and retry"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: ConfigPathMissing, message: "file setters.yaml doesn't exist, please ensure the file specified in \"configPath\" exists and retry"}},
 		},
 		{
-			name: "Scalar with zero count setter",
+			name: "Scalar with both ConfigMap and ConfigPath fnConfig",
 			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
@@ -183,23 +191,23 @@ pipeline:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
       configMap:
         app: my-app
-        foo: bar
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  app: other-app
 `, "test.yaml": `apiVersion: v1
 kind: Service
 metadata:
   name: my-app # kpt-set: ${app}
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  labels:
-    app: my-app # kpt-set: ${app}
-  name: mungebot
 `},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}, {Name: "foo", Value: "bar", Count: 0, Type: "str"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: Ambiguous, message: "apply-setters fn in pipeline.mutators[0] declares both ConfigMap and ConfigPath fnConfig, please declare only one of them"}},
 		},
 		{
-			name: "Scalar with two apply-setter configMap declarations",
+			name: "Scalar with inline functionConfig",
 			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
@@ -207,43 +215,76 @@ metadata:
 pipeline:
   mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
-      configMap:
-        app: my-app-old
-        foo: bar
+      functionConfig:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: setters
+        data:
+          app: my-app
+          tag: "1.0"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  annotations:
+    tag: "1.0" # kpt-set: ${tag}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "tag", Value: "1.0", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "float", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar with both inline functionConfig and ConfigMap fnConfig",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
       configMap:
         app: my-app
-        baz: qux
+      functionConfig:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: setters
+        data:
+          app: other-app
 `, "test.yaml": `apiVersion: v1
 kind: Service
 metadata:
   name: my-app # kpt-set: ${app}
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  labels:
-    app: my-app # kpt-set: ${app}
-  name: mungebot
 `},
-			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, Type: "str"}, {Name: "foo", Value: "bar", Count: 0, Type: "str"}, {Name: "baz", Value: "qux", Count: 0, Type: "str"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: Ambiguous, message: "apply-setters fn in pipeline.mutators[0] declares both inline functionConfig and ConfigMap or ConfigPath fnConfig, please declare only one of them"}},
 		},
 		{
-			name: "Mapping Simple",
-			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
-kind: Deployment
+			name: "Scalar with inline functionConfig without data",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
 metadata:
-  name: nginx-deployment
-spec:
-  images: # kpt-set: ${images}
-    - ubuntu
-    - hbase
- `},
-			expectedResult: []*Result{{Name: "images", Value: "[hbase, ubuntu]", Count: 1, Type: "array"}},
-			warnings:       []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      functionConfig:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: setters
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: NoFunctionConfig, message: "unable to find data of the inline functionConfig of apply-setters fn in pipeline.mutators[0]"}},
 		},
 		{
-			name: "Mapping with kptfile and setterYml",
+			name: "Scalar with configPath in nested directory",
 			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
@@ -251,281 +292,3388 @@ metadata:
 pipeline:
   mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
-      configPath: setters.yaml
-`, "setters.yaml": `apiVersion: v1
+      configPath: config/setters.yaml
+`, "config/setters.yaml": `apiVersion: v1
 kind: ConfigMap
 metadata:
-  name: apply-setters-fn-config
+  name: setters
 data:
-  images: |
-      - ubuntu
-      - hbase
-`, "test.yaml": `apiVersion: apps/v1
-kind: Deployment
+  app: my-app
+`, "test.yaml": `apiVersion: v1
+kind: Service
 metadata:
-  name: nginx-deployment
-spec:
-  images: # kpt-set: ${images}
-    - ubuntu
-    - hbase
- `},
-			expectedResult: []*Result{{Name: "images", Value: "[ubuntu, hbase]", Count: 1, Type: "array"}},
+  name: my-app # kpt-set: ${app}
+`},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
 		},
 		{
-			name: "Mapping with ConfigMap and ConfigPath apply-setter declarations",
-			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+			name: "Scalar with Kptfile in subpackage",
+			resourceMap: map[string]string{"sub/Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
-  name: test
+  name: sub
 pipeline:
   mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
       configPath: setters.yaml
-    - image: gcr.io/kpt-fn/apply-setters:v0.2
-      configMap:
-        baz: qux
-`, "setters.yaml": `apiVersion: v1
+`, "sub/setters.yaml": `apiVersion: v1
 kind: ConfigMap
 metadata:
-  name: apply-setters-fn-config
+  name: setters
 data:
-  images: |
-      - ubuntu
-      - hbase
-`, "test.yaml": `apiVersion: apps/v1
-kind: Deployment
+  app: my-app
+  foo: bar
+`, "sub/test.yaml": `apiVersion: v1
+kind: Service
 metadata:
-  name: nginx-deployment
-spec:
-  images: # kpt-set: ${images}
-    - ubuntu
-    - hbase
- `},
-			expectedResult: []*Result{{Name: "images", Value: "[ubuntu, hbase]", Count: 1, Type: "array"}, {Name: "baz", Value: "qux", Count: 0, Type: "str"}},
+  name: my-app # kpt-set: ${app}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"sub/test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "foo", Value: "bar", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"},
+			},
 		},
 		{
-			name: "Scalar and Mapping",
-			resourceMap: map[string]string{"test.yaml": `apiVersion: dns.cnrm.cloud.google.com/v1beta1
-kind: DNSRecordSet
+			name: "Scalar with multiple Kptfiles in subpackages",
+			resourceMap: map[string]string{"a/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
 metadata:
-  name: dnsrecordset-sample-mx # kpt-set: ${record-set-name}
-  labels:
-    typettl: "MX-300" # kpt-set: ${type}-${ttl}
-spec:
-  name: "mail.example.com." # kpt-set: ${domain}
-  type: "MX" # kpt-set: ${type}
-  ttl: 300 # kpt-set: ${ttl}
-  managedZoneRef:
-    name: dnsrecordset-dep-mx # kpt-set: ${managed-zone-name}
-  rrdatas: # kpt-set: ${records}
-    - "5 gmr-stmp-in.l.google.com."
-    - "10 alt1.gmr-stmp-in.l.google.com."
-    - "10 alt2.gmr-stmp-in.l.google.com."
-    - "10 alt3.gmr-stmp-in.l.google.com."
-    - "10 alt4.gmr-stmp-in.l.google.com."
+  name: a
+`, "b/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: b
+`, "a/test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
 `},
-			expectedResult: []*Result{
-				{Name: "record-set-name", Value: "dnsrecordset-sample-mx", Count: 1, Type: "str"},
-				{Name: "type", Value: "MX", Count: 2, Type: "str"},
-				{Name: "domain", Value: "mail.example.com.", Count: 1, Type: "str"},
-				{Name: "managed-zone-name", Value: "dnsrecordset-dep-mx", Count: 1, Type: "str"},
-				{Name: "ttl", Value: "300", Count: 2, Type: "int"},
-				{Name: "records", Value: "[10 alt1.gmr-stmp-in.l.google.com., 10 alt2.gmr-stmp-in.l.google.com., 10 alt3.gmr-stmp-in.l.google.com., 10 alt4.gmr-stmp-in.l.google.com., 5 gmr-stmp-in.l.google.com.]", Count: 1, Type: "array"},
-			},
-			warnings: []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"a/test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: Ambiguous, message: "unable to find Kptfile of the root package, found multiple Kptfiles a/Kptfile, b/Kptfile"}},
 		},
 		{
-			name: "with subpackages",
+			name: "Scalar filtered by kinds",
 			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
-  name: project-package
+  name: test
 pipeline:
   mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
-      configPath: setters.yaml
-`, "setters.yaml": `apiVersion: v1
-kind: ConfigMap
+      configMap:
+        app: my-app
+        replicas: "3"
+`, "test.yaml": `apiVersion: v1
+kind: Service
 metadata:
-  name: apply-setters-fn-config
-data:
-  folder-name: name.of.folder
-  folder-namespace: hierarchy
-  networking-namespace: networking
-  project-id: project-id
-`, "test.yaml": `apiVersion: resourcemanager.cnrm.cloud.google.com/v1beta1
-kind: Project
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: project-id # kpt-set: ${project-id}
-  namespace: projects # kpt-set: ${projects-namespace}
-  annotations:
-    cnrm.cloud.google.com/auto-create-network: "false"
+  name: my-app # kpt-set: ${app}
 spec:
-  name: project-id # kpt-set: ${project-id}
-  billingAccountRef:
-    external: "AAAAAA-BBBBBB-CCCCCC" # kpt-set: ${billing-account-id}
-  folderRef:
-    name: name.of.folder # kpt-set: ${folder-name}
-    namespace: hierarchy # kpt-set: ${folder-namespace}
-`, "subpkg/vpc.yaml": `apiVersion: compute.cnrm.cloud.google.com/v1beta1
-kind: ComputeNetwork
+  replicas: 3 # kpt-set: ${replicas}
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
 metadata:
-  name: network-name # kpt-set: ${network-name}
-  namespace: networking # kpt-set: ${networking-namespace}
-  annotations:
-    cnrm.cloud.google.com/project-id: project-id # kpt-set: ${project-id}
-spec:
-  autoCreateSubnetworks: false
-  deleteDefaultRoutesOnCreate: false
-  routingMode: GLOBAL
-`, "subpkg/setters.yaml": `apiVersion: v1
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: v1
 kind: ConfigMap
 metadata:
-  name: setters
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
 data:
-  foo: bar
-  network-name: network-name
-  networking-namespace: networking
-  project-id: project-id
-`, "subpkg/Kptfile": `apiVersion: kpt.dev/v1
+  kinds: apps/v1/Deployment,Service
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Setter kinds mismatching the Kptfile declarations",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
 kind: Kptfile
 metadata:
-  name: vpc-package
+  name: test
 pipeline:
   mutators:
     - image: gcr.io/kpt-fn/apply-setters:v0.2
-      configPath: setters.yaml
+      configMap:
+        images: hbase,ubuntu
+        app: "[my-app]"
+`, "test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  images: # kpt-set: ${images}
+    - hbase
+    - ubuntu
 `},
 			expectedResult: []*Result{
-				{Name: "billing-account-id", Value: "AAAAAA-BBBBBB-CCCCCC", Count: 1, Type: "str"},
-				{Name: "folder-name", Value: "name.of.folder", Count: 1, Type: "str"},
-				{Name: "folder-namespace", Value: "hierarchy", Count: 1, Type: "str"},
-				{Name: "network-name", Value: "network-name", Count: 1, Type: "str"},
-				{Name: "networking-namespace", Value: "networking", Count: 1, Type: "str"},
-				{Name: "project-id", Value: "project-id", Count: 3, Type: "str"},
-				{Name: "projects-namespace", Value: "projects", Count: 1, Type: "str"},
+				{Name: "app", Value: "[my-app]", Values: []string{"my-app"}, Count: 0, Type: "array", Source: "pipeline.mutators[0]"},
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Overridden: true},
+				{Name: "images", Value: "hbase,ubuntu", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"},
+				{Name: "images", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KindMismatch, message: `setter "app" is declared as an array in the Kptfile but parameterizes scalar fields in [test.yaml]`},
+				{Reason: KindMismatch, message: `setter "images" is declared as a scalar in the Kptfile but parameterizes array fields in [test.yaml], array setter values must be declared as YAML lists e.g. "[a, b]"`},
 			},
 		},
 		{
-			name: "multi type setters",
-			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+			name: "Scalar with double brace syntax",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: {{app}}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.16 # kpt-set: nginx:{{tag:-latest}} (legacy)
+          args: # kpt-set: {{args}}
+            - --debug
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  syntax: "{{}}"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "tag", Value: "1.16", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "float", Files: []string{"test.yaml"}, Default: "latest"},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Scalar scoped to changed files",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        replicas: "3"
+`, "service.yaml": `apiVersion: v1
 kind: Service
 metadata:
   name: my-app # kpt-set: ${app}
----
-apiVersion: apps/v1
+`, "base/deployment.yaml": `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  labels:
-    app: my-app # kpt-set: ${app}
-    pi: 3.14 # kpt-set: ${pi}
-  name: mungebot
+  name: my-app # kpt-set: ${app}
+`, "deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
 spec:
   replicas: 3 # kpt-set: ${replicas}
-  paused: true # kpt-set: ${paused}
 `},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  changedFiles: service.yaml,./base/deployment.yaml
+`,
 			expectedResult: []*Result{
-				{Name: "app", Value: "my-app", Count: 2, Type: "str"},
-				{Name: "paused", Value: "true", Count: 1, Type: "bool"},
-				{Name: "pi", Value: "3.14", Count: 1, Type: "float"},
-				{Name: "replicas", Value: "3", Count: 1, Type: "int"}},
-			warnings: []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+				{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"base/deployment.yaml", "service.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "replicas", Value: "3", Count: 0, Type: "str", ValueType: "int", Source: "pipeline.mutators[0]"},
+			},
 		},
 		{
-			name: "multiple interpolated type setters",
-			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+			name: "Scalar in resources rendered into one file",
+			resourceMap: map[string]string{"rendered.yaml": `apiVersion: v1
 kind: Service
 metadata:
   name: my-app # kpt-set: ${app}
+  labels:
+    helm.sh/chart: my-app-0.1.0
 ---
 apiVersion: apps/v1
 kind: Deployment
 metadata:
+  name: my-app # kpt-set: ${app}
   labels:
-    app: my-app-3 # kpt-set: ${app}-${replicas}
-  name: mungebot
-spec:
-  replicas: 3 # kpt-set: ${replicas}
-  paused: true # kpt-set: ${paused}
+    helm.sh/chart: my-app-0.1.0
 ---
 apiVersion: apps/v1
 kind: Deployment
 metadata:
+  name: my-app # kpt-set: ${app}
   labels:
-    app: my-app-3 # kpt-set: ${app}-${replicas}
-  name: mungebot2
+    helm.sh/chart: my-app-0.1.0
+  annotations:
+    helm.sh/hook: pre-install
 `},
 			expectedResult: []*Result{
-				{Name: "app", Value: "my-app", Count: 3, Type: "str"},
-				{Name: "paused", Value: "true", Count: 1, Type: "bool"},
-				{Name: "replicas", Value: "3", Count: 3, Type: "int"}},
-			warnings: []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+				{Name: "app", Value: "my-app", Count: 3, FieldCount: 3, ResourceCount: 3, Type: "str", ValueType: "string", Files: []string{"rendered.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
 		},
 		{
-			name: "ambiguous setter value picks first value",
-			resourceMap: map[string]string{"test.yaml": `apiVersion: container.cnrm.cloud.google.com/v1beta1
-kind: ContainerCluster
+			name: "Malformed setter patterns",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
 metadata:
-  name: example-us-east4 # kpt-set: ${cluster-name}
-  annotations:
-    cnrm.cloud.google.com/project-id: platform-project-id # kpt-set: ${platform-project-id}
+  name: my-app # kpt-set: ${app}
+  namespace: default # kpt-set: ${namespace
 spec:
-  subnetworkRef:
-    name: platform-project-id-example-us-east4 # kpt-set: ${platform-project-id}-${cluster-name}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx # kpt-set: ${foo-${bar}
 `},
 			expectedResult: []*Result{
-				{Name: "cluster-name", Value: "example-us-east4", Count: 2, Type: "str"},
-				{Name: "platform-project-id", Value: "platform-project-id", Count: 2, Type: "str"}},
-			warnings: []*WarnSetterDiscovery{{"unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: MalformedPattern, message: `malformed setter pattern "${namespace" of metadata.namespace in test.yaml: setter reference "${namespace" is not closed with "}"`,
+					File: "test.yaml", Path: "metadata.namespace"},
+				{Reason: MalformedPattern, message: `malformed setter pattern "${foo-${bar}" of spec.template.spec.containers[0].image in test.yaml: setter reference "${bar}" is nested in "${foo-${bar}"`,
+					File: "test.yaml", Path: "spec.template.spec.containers[0].image"},
+			},
 		},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			require := require.New(t)
-			pkgDir := setupInputs(t, test.resourceMap)
-			defer os.RemoveAll(pkgDir)
+		{
+			name: "Scalar on boolean and null fields",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app
+data:
+  enabled: true # kpt-set: ${flag}
+  disabled: False # kpt-set: ${other-flag}
+  field: null # kpt-set: ${x}
+  tilde: ~ # kpt-set: ${y}
+  quoted: "null" # kpt-set: ${z}
+  empty: # kpt-set: ${e}
+`},
+			expectedResult: []*Result{
+				{Name: "e", Value: "null", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "null", ValueType: "null", Files: []string{"test.yaml"}},
+				{Name: "flag", Value: "true", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "bool", ValueType: "bool", Files: []string{"test.yaml"}},
+				{Name: "other-flag", Value: "False", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "bool", ValueType: "bool", Files: []string{"test.yaml"}},
+				{Name: "x", Value: "null", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "null", ValueType: "null", Files: []string{"test.yaml"}},
+				{Name: "y", Value: "null", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "null", ValueType: "null", Files: []string{"test.yaml"}},
+				{Name: "z", Value: "null", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "null", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Setter values referencing other setters",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        image: gcr.io/${project}/nginx:${tag}
+        project: my-project
+        tag: "1.16"
+        args: '["--project=${project}"]'
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: gcr.io/${project}/nginx:${tag} # kpt-set: ${image}
+`},
+			expectedResult: []*Result{
+				{Name: "args", Value: "[--project=${project}]", Values: []string{"--project=${project}"}, Count: 0, Type: "array", DependsOn: []string{"project"}, Source: "pipeline.mutators[0]"},
+				{Name: "image", Value: "gcr.io/${project}/nginx:${tag}", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, DependsOn: []string{"project", "tag"}, Source: "pipeline.mutators[0]"},
+				{Name: "project", Value: "my-project", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"},
+				{Name: "tag", Value: "1.16", Count: 0, Type: "str", ValueType: "float", Source: "pipeline.mutators[0]"},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: UnresolvedDependency, message: `setter "args" depends on setters [project] referenced in its value, which are only substituted by another apply-setters pass`},
+				{Reason: UnresolvedDependency, message: `setter "image" depends on setters [project, tag] referenced in its value, which are only substituted by another apply-setters pass`},
+			},
+		},
+		{
+			name: "Unresolved setter placeholder",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  g: foo-${bar} # kpt-set: foo-${bar}
+`},
+			expectedResult: []*Result{
+				{Name: "bar", Value: "${bar}", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Setter used on scalar and array fields",
+			resourceMap: map[string]string{"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          args: [--debug] # kpt-set: ${args}
+`, "job.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          command:
+            - run
+            - --debug # kpt-set: ${args}
+`},
+			expectedResult: []*Result{
+				{Name: "args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"deployment.yaml"}},
+				{Name: "args", Value: "--debug", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"job.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: KindMismatch, message: `setter "args" parameterizes both scalar fields in [job.yaml] and array fields in [deployment.yaml], which apply-setters can't set consistently`},
+			},
+		},
+		{
+			name: "Setters in head comments",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  # the name of the app
+  # kpt-set: ${app}
+  name: my-app
+spec:
+  # kpt-set: ${replicas}
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: app
+          # kpt-set: ${args}
+          args:
+            - --debug
+          command:
+            # kpt-set: ${shell}
+            - /bin/sh
+          # a note which is not a setter
+          image: nginx:1.16
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "shell", Value: "/bin/sh", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Scalar with included and excluded paths",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        replicas: "3"
+`, "apps/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+`, "apps/test/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: fixture # kpt-set: ${app}
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+`, "deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  includePaths: apps/**
+  excludePaths: "**/test/**"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"apps/deployment.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "replicas", Value: "3", Count: 0, Type: "str", ValueType: "int", Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar overridden in resources",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        replicas: "3"
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: edited-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Overridden: true, Source: "pipeline.mutators[0]"},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar with default values",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: foo-1.0 # kpt-set: foo-${tag:-latest}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "tag", Value: "1.0", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "float", Files: []string{"test.yaml"}, Default: "latest"},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Scalar with warnings in strict mode",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  strict: "true"
+`,
+			errMsg: "found setter discovery warnings in strict mode: unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present",
+		},
+		{
+			name: "Scalar without warnings in strict mode",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  strict: "true"
+  includeKptfile: "false"
+`,
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+		},
+		{
+			name: "Scalar with unused and undeclared setters in errorOn",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        tag: "1.0"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: prod # kpt-set: ${ns}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: "[unused, undeclared]"
+`,
+			errMsg: `found setter problems of the errorOn categories [unused, undeclared]: setter "tag" is declared in the Kptfile but not used by any resource; ` +
+				`setter "ns" is used by resources but not declared in the Kptfile`,
+		},
+		{
+			name: "Scalar without conflicts in errorOn",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        tag: "1.0"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: prod # kpt-set: ${ns}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: conflict
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "ns", Value: "prod", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "tag", Value: "1.0", Count: 0, FieldCount: 0, ResourceCount: 0, Type: "str", ValueType: "float", Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar with conflicts in errorOn",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: other-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  errorOn: conflict
+`,
+			errMsg: `found setter problems of the errorOn categories [conflict]: setter "app" has conflicting values "my-app" in [test.yaml], "other-app" in [test.yaml]`,
+		},
+		{
+			name: "Scalar with ignored local-config annotation",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+  annotations:
+    config.kubernetes.io/local-config: "true"
+data:
+  app: my-app
+`, "scaffold.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-scaffold # kpt-set: ${app}-scaffold
+  annotations:
+    config.kubernetes.io/local-config: "true"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  ignoreAnnotations: config.kubernetes.io/local-config=true
+`,
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
+		},
+		{
+			name: "Scalar with untagged fields in errorOn",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app # kpt-set: ${app}
+data:
+  example: my-app
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  detectUntagged: "true"
+  errorOn: untagged
+`,
+			errMsg: `found setter problems of the errorOn categories [untagged]: heuristic: candidate unparameterized field data.example in test.yaml ` +
+				`has the value "my-app" of setters [app] but no setter comment, this may be a false positive`,
+		},
+		{
+			name: "Scalar with namespace",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        tenant: team-b
+`, "team-a.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: team-a
+`, "team-b.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: team-b # kpt-set: ${tenant}
+`, "namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-b # kpt-set: ${tenant}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  namespace: team-a
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"team-a.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "tenant", Value: "team-b", Count: 0, FieldCount: 0, ResourceCount: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar with max warnings",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    a: a # kpt-set: ${a
+    b: b # kpt-set: ${b
+    c: c # kpt-set: ${c
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  maxWarnings: "2"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: MalformedPattern, message: `malformed setter pattern "${a" of metadata.labels.a in test.yaml: setter reference "${a" is not closed with "}"`,
+					File: "test.yaml", Path: "metadata.labels.a"},
+				{Reason: TruncatedWarnings, message: "and 2 more warnings, not listed as maxWarnings is 2"},
+			},
+		},
+		{
+			name: "Scalar with split setters",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app # kpt-set: ${app}
+data:
+  hosts: a.example.com, b.example.com # kpt-set: ${hosts}
+  zones: us-east1 # kpt-set: ${zones}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  splitSetters: hosts,zones
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "hosts", Value: "[a.example.com, b.example.com]", Values: []string{"a.example.com", "b.example.com"}, Count: 1, FieldCount: 1, ResourceCount: 1,
+					Type: "array", Files: []string{"test.yaml"}},
+				{Name: "zones", Value: "us-east1", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+			},
+		},
+		{
+			name: "Mapping list of objects",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: my-app
+spec:
+  containers: # kpt-set: ${containers}
+    - name: nginx
+      image: nginx:1.21
+    - name:   sidecar
+      image: envoy
+`},
+			expectedResult: []*Result{{Name: "containers", Value: "[name: nginx image: nginx:1.21, name: sidecar image: envoy]",
+				Values: []string{"name: nginx image: nginx:1.21", "name: sidecar image: envoy"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}}},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Mapping list of objects with array value field",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        containers: |
+          - name: nginx
+            image: nginx:1.21
+          - image: envoy
+`, "test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: my-app
+spec:
+  containers: # kpt-set: ${containers}
+    - name: nginx
+      image: nginx:1.21
+    - image: envoy
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  arrayValueField: name
+`,
+			expectedResult: []*Result{{Name: "containers", Value: "[image: envoy, nginx]", Values: []string{"image: envoy", "nginx"}, Count: 1, FieldCount: 1, ResourceCount: 1,
+				Type: "array", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
+		},
+		{
+			name: "Scalar and array with ignore directive",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app # kpt-set: ${app}
+data:
+  example: my-app # kpt-set: ${app} # list-setters:ignore
+  images: # kpt-set: ${images} # list-setters:ignore
+    - nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  detectUntagged: "true"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "images", Value: "[ubuntu]", Values: []string{"ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+			},
+		},
+		{
+			name: "Scalar with Kptfile excluded",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        foo: bar
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  includeKptfile: "false"
+`,
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+		},
+		{
+			name: "Scalar with zero count setter",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        foo: bar
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+  name: mungebot
+`},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}, {Name: "foo", Value: "bar", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"}},
+		},
+		{
+			name: "Scalar with unused setter reported",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        foo: bar
+        images: |
+          - ubuntu
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  reportUnused: "true"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "foo", Value: "bar", Count: 0, Type: "str", ValueType: "string", Status: UnusedStatus, Source: "pipeline.mutators[0]"},
+				{Name: "images", Value: "[ubuntu]", Values: []string{"ubuntu"}, Count: 0, Type: "array", Status: UnusedStatus, Source: "pipeline.mutators[0]"},
+			},
+		},
+		{
+			name: "Scalar with undeclared setter reported",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        image: nginx
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  image: nginx # kpt-set: ${imge}
+  args: # kpt-set: ${args}
+    - --debug
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  reportUnused: "true"
+  reportUndeclared: "true"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}, Status: UndeclaredStatus},
+				{Name: "image", Value: "nginx", Count: 0, Type: "str", ValueType: "string", Status: UnusedStatus, Source: "pipeline.mutators[0]"},
+				{Name: "imge", Value: "nginx", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Status: UndeclaredStatus},
+			},
+		},
+		{
+			name: "Scalar undeclared setter not reported without Kptfile",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  reportUndeclared: "true"
+`,
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Scalar filtered by name pattern",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  image: nginx:1.2 # kpt-set: ${image-name}:${image-tag}
+  args: # kpt-set: ${image-args}
+    - --debug
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  namePattern: ^image-.*
+`,
+			expectedResult: []*Result{
+				{Name: "image-args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "image-name", Value: "nginx", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "image-tag", Value: "1.2", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "float", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "embedded yaml",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config # kpt-set: ${name}
+data:
+  config.yaml: |
+    project: my-project # kpt-set: ${project}
+    zones: # kpt-set: ${zones}
+      - us-east1-b
+  notes: "see # kpt-set: docs"
+  plain: some text
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  embeddedYAML: "true"
+`,
+			expectedResult: []*Result{
+				{Name: "name", Value: "my-config", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "project", Value: "my-project", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "zones", Value: "[us-east1-b]", Values: []string{"us-east1-b"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "value type of quoted number",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  replicas: "3" # kpt-set: ${count}
+  paused: false # kpt-set: ${paused}
+`},
+			expectedResult: []*Result{
+				{Name: "count", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "paused", Value: "false", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "bool", ValueType: "bool", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "setter comment with trailing description",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app # kpt-set: ${app} (legacy)
+data:
+  owner: jane doe # kpt-set: ${first} ${last} owner of the app
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "first", Value: "jane", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "last", Value: "doe", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "inconsistent setter values in a document",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: prod
+  labels:
+    app: web # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 3, FieldCount: 3, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"},
+					Inconsistencies: []Inconsistency{{File: "test.yaml", Resource: "Deployment/my-app.prod",
+						Fields: []FieldValue{{Path: "metadata.name", Value: "my-app"}, {Path: "metadata.labels.app", Value: "web"}}}}},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: ConflictingValues, message: `setter "app" has conflicting values "my-app" in [test.yaml], "web" in [test.yaml]`},
+				{Reason: InconsistentValues, message: `setter "app" has inconsistent values in Deployment/my-app.prod of test.yaml: metadata.name="my-app", metadata.labels.app="web"`,
+					File: "test.yaml"},
+			},
+		},
+		{
+			name: "kustomization inline patches",
+			resourceMap: map[string]string{"kustomization.yaml": `resources:
+- deployment.yaml
+images:
+- name: nginx
+  newTag: 1.16.1 # kpt-set: ${tag}
+patchesStrategicMerge:
+- |-
+  apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: nginx
+  spec:
+    replicas: 3 # kpt-set: ${replicas}
+patches:
+- target:
+    kind: Deployment
+  patch: |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: nginx
+      namespace: prod # kpt-set: ${namespace}
+patchesJson6902:
+- target:
+    kind: Deployment
+    name: nginx
+  patch: |-
+    - op: replace
+      path: /metadata/labels/env
+      value: dev # kpt-set: ${env}
+`},
+			expectedResult: []*Result{
+				{Name: "env", Value: "dev", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"kustomization.yaml"}},
+				{Name: "namespace", Value: "prod", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"kustomization.yaml"}},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"kustomization.yaml"}},
+				{Name: "tag", Value: "1.16.1", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"kustomization.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "inline patches outside of kustomizations",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: patches
+patchesStrategicMerge:
+- |-
+  spec:
+    replicas: 3 # kpt-set: ${replicas}
+`},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "setter names violating the name regex",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app-name}
+spec:
+  replicas: 3 # kpt-set: ${Replicas}
+  images: # kpt-set: ${image_list}
+    - ubuntu
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  nameRegex: ^[a-z0-9]([a-z0-9-]*[a-z0-9])?$
+`,
+			expectedResult: []*Result{
+				{Name: "Replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "app-name", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "image_list", Value: "[ubuntu]", Values: []string{"ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: InvalidName, message: `setter name "Replicas" doesn't match nameRegex "^[a-z0-9]([a-z0-9-]*[a-z0-9])?$"`},
+				{Reason: InvalidName, message: `setter name "image_list" doesn't match nameRegex "^[a-z0-9]([a-z0-9-]*[a-z0-9])?$"`},
+			},
+		},
+		{
+			name: "empty setter value",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}${suffix}
+  namespace: "" # kpt-set: ${namespace}
+`},
+			expectedResult: []*Result{
+				{Name: "namespace", Value: "", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Empty: true},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: AmbiguousCapture, message: `ambiguous setter pattern "${app}${suffix}" of metadata.name in test.yaml, the setter references ${app}${suffix} ` +
+					"aren't separated by a literal so their values can't be derived reliably", File: "test.yaml", Path: "metadata.name"},
+			},
+		},
+		{
+			name: "irregular setter comment spacing",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app #kpt-set:${app}
+  namespace: dev #  kpt-set:   ${env}
+spec:
+  replicas: 3 #	kpt-set:	${replicas}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "env", Value: "dev", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "quoted values",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: "my-app" # kpt-set: ${app}
+  labels:
+    quote: "'q'" # kpt-set: '${quote}'
+spec:
+  ports:
+    - port: "8080" # kpt-set: ${port}
+      targetPort: '8080' # kpt-set: ${port}
+      nodePort: 30000 # kpt-set: ${node-port}
+    - port: "9090" # kpt-set: "${metrics-port}"
+      targetPort: '9090' # kpt-set: '${metrics-port}'
+      nodePort: 30001 # kpt-set: "${metrics-node-port}"
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "metrics-node-port", Value: "30001", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "metrics-port", Value: "9090", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "str", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "node-port", Value: "30000", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "port", Value: "8080", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "str", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "quote", Value: "q", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "empty setter value in pattern",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}-${stage}
+  labels:
+    app: my-app # kpt-set: my-app${suffix}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "stage", Value: "app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "suffix", Value: "", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Empty: true},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "block scalars",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  config: | # kpt-set: ${cfg}
+    log-level: debug
+    port: 8080
+  motd: > # kpt-set: Welcome to ${env}
+    Welcome to
+    staging
+  banner: # kpt-set: ${banner}
+    |
+    hello
+`},
+			expectedResult: []*Result{
+				{Name: "banner", Value: "hello", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "cfg", Value: "log-level: debug\nport: 8080", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "env", Value: "staging", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "embedded multi-document yaml",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: manifests
+data:
+  manifests.yaml: |
+    apiVersion: v1
+    kind: Namespace
+    metadata:
+      name: dev # kpt-set: ${env}
+    ---
+    apiVersion: v1
+    kind: Service
+    metadata:
+      name: [broken # kpt-set: ${broken}
+    ---
+    apiVersion: v1
+    kind: ServiceAccount
+    metadata:
+      name: my-app # kpt-set: ${app}
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  embeddedYAML: "true"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "env", Value: "dev", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: InvalidEmbeddedDocument, message: "unable to parse embedded document 1 of data.manifests.yaml in test.yaml: yaml: line 4: did not find expected ',' or ']'",
+					File: "test.yaml", Path: "data.manifests.yaml"},
+			},
+		},
+		{
+			name: "embedded yaml disabled",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config # kpt-set: ${name}
+data:
+  config.yaml: |
+    project: my-project # kpt-set: ${project}
+`},
+			expectedResult: []*Result{
+				{Name: "name", Value: "my-config", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "custom setter comment",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # vendor-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  args: # vendor-set: ${args}
+    - --debug
+`},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  setterComment: "# vendor-set:"
+`,
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "args", Value: "[--debug]", Values: []string{"--debug"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Scalar with two apply-setter configMap declarations",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app-old
+        foo: bar
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        baz: qux
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+  name: mungebot
+`},
+			expectedResult: []*Result{{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[1]"}, {Name: "foo", Value: "bar", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[0]"}, {Name: "baz", Value: "qux", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[1]"}},
+			warnings: []*WarnSetterDiscovery{{Reason: DuplicateSetters, message: "setters are declared by multiple apply-setters fns in Kptfile, the last declaration is used: " +
+				`app is declared as "my-app-old" in pipeline.mutators[0] and "my-app" in pipeline.mutators[1]`}},
+		},
+		{
+			name: "Mapping Simple",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+ `},
+			expectedResult: []*Result{{Name: "images", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Mapping block and flow styles",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  block: # kpt-set: ${block}
+    - ubuntu
+    - hbase
+  flow: [ubuntu, hbase] # kpt-set: ${flow}
+  flowKey: # kpt-set: ${flow-key}
+    [ubuntu, hbase]
+  flowEmpty: [] # kpt-set: ${flow-empty}
+ `},
+			expectedResult: []*Result{
+				{Name: "block", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "flow", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "flow-key", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+				{Name: "flow-empty", Value: "[]", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Mapping preserve order",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+ `},
+			fnConfig: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  preserveOrder: "true"
+`,
+			expectedResult: []*Result{{Name: "images", Value: "[ubuntu, hbase]", Values: []string{"ubuntu", "hbase"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}}},
+			warnings:       []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Mapping with kptfile and setterYml",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-setters-fn-config
+data:
+  images: |
+      - ubuntu
+      - hbase
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+ `},
+			expectedResult: []*Result{{Name: "images", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
+		},
+		{
+			name: "Mapping with parameterized keys",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: regions
+data:
+  zones:
+    us-east1: # kpt-set: ${region}
+      primary: us-east1-b # kpt-set: ${region}-b
+    backup-us-west1: # kpt-set: backup-${backup-region}
+      primary: us-west1-a
+`},
+			expectedResult: []*Result{
+				{Name: "backup-region", Value: "us-west1", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "key", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "region", Value: "us-east1", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "key", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "Mapping with values drifted from kptfile",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        images: "[ubuntu, hbase, nginx]"
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - alpine
+`},
+			expectedResult: []*Result{{Name: "images", Value: "[hbase, nginx, ubuntu]", Values: []string{"hbase", "nginx", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: ArrayValuesMismatch, message: `array setter "images" in test.yaml doesn't match the values declared in the Kptfile, added: [alpine], removed: [hbase, nginx]`,
+					File: "test.yaml"},
+			},
+		},
+		{
+			name: "Mapping with ConfigMap and ConfigPath apply-setter declarations",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        baz: qux
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-setters-fn-config
+data:
+  images: |
+      - ubuntu
+      - hbase
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+ `},
+			expectedResult: []*Result{{Name: "images", Value: "[hbase, ubuntu]", Values: []string{"hbase", "ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"}, {Name: "baz", Value: "qux", Count: 0, Type: "str", ValueType: "string", Source: "pipeline.mutators[1]"}},
+		},
+		{
+			name: "Scalar and Mapping",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: dns.cnrm.cloud.google.com/v1beta1
+kind: DNSRecordSet
+metadata:
+  name: dnsrecordset-sample-mx # kpt-set: ${record-set-name}
+  labels:
+    typettl: "MX-300" # kpt-set: ${type}-${ttl}
+spec:
+  name: "mail.example.com." # kpt-set: ${domain}
+  type: "MX" # kpt-set: ${type}
+  ttl: 300 # kpt-set: ${ttl}
+  managedZoneRef:
+    name: dnsrecordset-dep-mx # kpt-set: ${managed-zone-name}
+  rrdatas: # kpt-set: ${records}
+    - "5 gmr-stmp-in.l.google.com."
+    - "10 alt1.gmr-stmp-in.l.google.com."
+    - "10 alt2.gmr-stmp-in.l.google.com."
+    - "10 alt3.gmr-stmp-in.l.google.com."
+    - "10 alt4.gmr-stmp-in.l.google.com."
+`},
+			expectedResult: []*Result{
+				{Name: "record-set-name", Value: "dnsrecordset-sample-mx", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "type", Value: "MX", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "domain", Value: "mail.example.com.", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "managed-zone-name", Value: "dnsrecordset-dep-mx", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "ttl", Value: "300", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+				{Name: "records", Value: "[10 alt1.gmr-stmp-in.l.google.com., 10 alt2.gmr-stmp-in.l.google.com., 10 alt3.gmr-stmp-in.l.google.com., 10 alt4.gmr-stmp-in.l.google.com., 5 gmr-stmp-in.l.google.com.]", Values: []string{"10 alt1.gmr-stmp-in.l.google.com.", "10 alt2.gmr-stmp-in.l.google.com.", "10 alt3.gmr-stmp-in.l.google.com.", "10 alt4.gmr-stmp-in.l.google.com.", "5 gmr-stmp-in.l.google.com."}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"}},
+			},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "with subpackages",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: project-package
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apply-setters-fn-config
+data:
+  folder-name: name.of.folder
+  folder-namespace: hierarchy
+  networking-namespace: networking
+  project-id: project-id
+`, "test.yaml": `apiVersion: resourcemanager.cnrm.cloud.google.com/v1beta1
+kind: Project
+metadata:
+  name: project-id # kpt-set: ${project-id}
+  namespace: projects # kpt-set: ${projects-namespace}
+  annotations:
+    cnrm.cloud.google.com/auto-create-network: "false"
+spec:
+  name: project-id # kpt-set: ${project-id}
+  billingAccountRef:
+    external: "AAAAAA-BBBBBB-CCCCCC" # kpt-set: ${billing-account-id}
+  folderRef:
+    name: name.of.folder # kpt-set: ${folder-name}
+    namespace: hierarchy # kpt-set: ${folder-namespace}
+`, "subpkg/vpc.yaml": `apiVersion: compute.cnrm.cloud.google.com/v1beta1
+kind: ComputeNetwork
+metadata:
+  name: network-name # kpt-set: ${network-name}
+  namespace: networking # kpt-set: ${networking-namespace}
+  annotations:
+    cnrm.cloud.google.com/project-id: project-id # kpt-set: ${project-id}
+spec:
+  autoCreateSubnetworks: false
+  deleteDefaultRoutesOnCreate: false
+  routingMode: GLOBAL
+`, "subpkg/setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  foo: bar
+  network-name: network-name
+  networking-namespace: networking
+  project-id: project-id
+`, "subpkg/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: vpc-package
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`},
+			expectedResult: []*Result{
+				{Name: "billing-account-id", Value: "AAAAAA-BBBBBB-CCCCCC", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "folder-name", Value: "name.of.folder", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "folder-namespace", Value: "hierarchy", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "network-name", Value: "network-name", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"subpkg/vpc.yaml"}},
+				{Name: "networking-namespace", Value: "networking", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"subpkg/vpc.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "project-id", Value: "project-id", Count: 3, FieldCount: 3, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"subpkg/vpc.yaml", "test.yaml"}, Source: "pipeline.mutators[0]"},
+				{Name: "projects-namespace", Value: "projects", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+			},
+		},
+		{
+			name: "multi type setters",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+    pi: 3.14 # kpt-set: ${pi}
+  name: mungebot
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  paused: true # kpt-set: ${paused}
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "paused", Value: "true", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "bool", ValueType: "bool", Files: []string{"test.yaml"}},
+				{Name: "pi", Value: "3.14", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "float", ValueType: "float", Files: []string{"test.yaml"}},
+				{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}}},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "multiple interpolated type setters",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app-3 # kpt-set: ${app}-${replicas}
+  name: mungebot
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  paused: true # kpt-set: ${paused}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app-3 # kpt-set: ${app}-${replicas}
+  name: mungebot2
+`},
+			expectedResult: []*Result{
+				{Name: "app", Value: "my-app", Count: 3, FieldCount: 3, ResourceCount: 3, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "paused", Value: "true", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "bool", ValueType: "bool", Files: []string{"test.yaml"}},
+				{Name: "replicas", Value: "3", Count: 3, FieldCount: 3, ResourceCount: 2, Type: "int", ValueType: "int", Files: []string{"test.yaml"}}},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "ambiguous setter value picks first value",
+			resourceMap: map[string]string{"test.yaml": `apiVersion: container.cnrm.cloud.google.com/v1beta1
+kind: ContainerCluster
+metadata:
+  name: example-us-east4 # kpt-set: ${cluster-name}
+  annotations:
+    cnrm.cloud.google.com/project-id: platform-project-id # kpt-set: ${platform-project-id}
+spec:
+  subnetworkRef:
+    name: platform-project-id-example-us-east4 # kpt-set: ${platform-project-id}-${cluster-name}
+`},
+			expectedResult: []*Result{
+				{Name: "cluster-name", Value: "example-us-east4", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}},
+				{Name: "platform-project-id", Value: "platform-project-id", Count: 2, FieldCount: 2, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"test.yaml"}}},
+			warnings: []*WarnSetterDiscovery{{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"}},
+		},
+		{
+			name: "conflicting setter values across files",
+			resourceMap: map[string]string{"dev.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: dev # kpt-set: ${env}
+`, "prod.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: prod # kpt-set: ${env}
+  labels:
+    env: prod # kpt-set: ${env}
+`},
+			expectedResult: []*Result{
+				{Name: "env", Value: "dev", Count: 3, FieldCount: 3, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"dev.yaml", "prod.yaml"}}},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: KptfileNotFound, message: "unable to find Kptfile, please include --include-meta-resources flag if a Kptfile is present"},
+				{Reason: ConflictingValues, message: `setter "env" has conflicting values "dev" in [dev.yaml], "prod" in [prod.yaml]`},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			pkgDir := setupInputs(t, test.resourceMap)
+			defer os.RemoveAll(pkgDir)
+
+			ls := New()
+			if test.fnConfig != "" {
+				require.NoError(Decode(yaml.MustParse(test.fnConfig), &ls))
+			}
+			inout := &kio.LocalPackageReadWriter{
+				PackagePath:        pkgDir,
+				NoDeleteFiles:      true,
+				PackageFileName:    "Kptfile",
+				MatchFilesGlob:     append(kio.DefaultMatch, "Kptfile"),
+				IncludeSubpackages: true,
+			}
+			err := kio.Pipeline{
+				Inputs:  []kio.Reader{inout},
+				Filters: []kio.Filter{&ls},
+				Outputs: []kio.Writer{inout},
+			}.Execute()
+			if test.errMsg != "" {
+				require.NotNil(err)
+				require.Contains(err.Error(), test.errMsg)
+			} else {
+				require.NoError(err)
+				require.ElementsMatch(ls.Warnings, test.warnings)
+				actualResources := ls.GetResults()
+				require.ElementsMatch(actualResources, test.expectedResult)
+			}
+
+		})
+	}
+}
+
+func TestListSettersFileCounts(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"deploy.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: my-app # kpt-set: ${app}
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+`, "svc/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"deploy.yaml": 2, "svc/service.yaml": 1}, ls.ScalarSetters["app"].Files)
+	require.Equal(t, map[string]int{"deploy.yaml": 1}, ls.ArraySetters["images"].Files)
+}
+
+func TestListSettersLocations(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+  name: mungebot
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Verbose = true
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"test.yaml"},
+			Locations: []Location{{File: "test.yaml", Line: 4, Column: 9, Path: "metadata.name"}, {File: "test.yaml", Line: 5, Column: 10, Document: 1, Path: "metadata.labels.app"}}, ValueBytes: 12},
+		{Name: "images", Value: "[ubuntu]", Values: []string{"ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"test.yaml"},
+			Locations: []Location{{File: "test.yaml", Line: 8, Column: 3, Document: 1, Path: "spec.images"}}, ValueBytes: 6},
+	}, ls.GetResults())
+	require.Equal(t, "Name: app, Value: my-app, Type: str, Count: 2, Locations: [test.yaml:4:9 metadata.name, test.yaml:5:10 metadata.labels.app in document 1], Value bytes: 12", ls.GetResults()[0].String())
+}
+
+func TestListSettersResourceListLocations(t *testing.T) {
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: my-app # kpt-set: ${app}
+      annotations:
+        config.kubernetes.io/path: a.yaml
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: my-app # kpt-set: ${app}
+      annotations:
+        config.kubernetes.io/path: b.yaml
+        config.kubernetes.io/index: '1'
+    spec:
+      replicas: 3 # kpt-set: ${replicas}
+`
+	nodes, err := (&kio.ByteReader{Reader: strings.NewReader(input), OmitReaderAnnotations: true}).Read()
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	ls := New()
+	ls.Verbose = true
+	_, err = ls.Filter(nodes)
+	require.NoError(t, err)
+	results := ls.GetResults()
+	require.Len(t, results, 2)
+	require.Equal(t, []Location{
+		{File: "a.yaml", Line: 4, Column: 9, Path: "metadata.name"},
+		{File: "b.yaml", Line: 4, Column: 9, Document: 1, Path: "metadata.name"},
+	}, results[0].Locations)
+	require.Equal(t, []Location{
+		{File: "b.yaml", Line: 9, Column: 13, Document: 1, Path: "spec.replicas"},
+	}, results[1].Locations)
+}
+
+func TestListSettersResourceLocations(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+  name: mungebot
+  namespace: prod
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Verbose = true
+	ls.IncludeResource = true
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []Location{
+		{File: "test.yaml", Line: 4, Column: 9, Path: "metadata.name", Resource: &ResourceRef{APIVersion: "v1", Kind: "Service", Name: "my-app"}},
+		{File: "test.yaml", Line: 5, Column: 10, Document: 1, Path: "metadata.labels.app", Resource: &ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "mungebot", Namespace: "prod"}},
+	}, ls.GetResults()[0].Locations)
+	require.Equal(t, "Name: app, Value: my-app, Type: str, Count: 2, Locations: [test.yaml:4:9 metadata.name (Service/my-app), test.yaml:5:10 metadata.labels.app in document 1 (Deployment/mungebot.prod)], Value bytes: 12",
+		ls.GetResults()[0].String())
+}
+
+func TestListSettersProvenance(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  annotations:
+    example.com/source: github.com/example/blueprints/app@v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app # kpt-set: ${app}
+  name: mungebot
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Verbose = true
+	ls.ProvenanceAnnotation = "example.com/source"
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []Location{
+		{File: "test.yaml", Line: 4, Column: 9, Path: "metadata.name", Provenance: "github.com/example/blueprints/app@v1"},
+		{File: "test.yaml", Line: 5, Column: 10, Document: 1, Path: "metadata.labels.app"},
+	}, ls.GetResults()[0].Locations)
+	require.Equal(t, "Name: app, Value: my-app, Type: str, Count: 2, Locations: [test.yaml:4:9 metadata.name from github.com/example/blueprints/app@v1, test.yaml:5:10 metadata.labels.app in document 1], Value bytes: 12",
+		ls.GetResults()[0].String())
+}
+
+func TestListSettersSARIF(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        tag: "1.0"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: prod # kpt-set: ${ns}
+  annotations:
+    owner: team # kpt-set: ${owner
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: other-app # kpt-set: ${app}
+  name: mungebot`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.OutputFormat = SARIFOutputFormat
+	ls.ReportUnused = true
+	ls.ReportUndeclared = true
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	out, err := ls.FormatResults()
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.JSONEq(t, `{"version":"2.1.0","$schema":"https://json.schemastore.org/sarif-2.1.0.json","runs":[{
+  "tool":{"driver":{"name":"list-setters","rules":[
+    {"id":"unused-setter","shortDescription":{"text":"Setter declared in the Kptfile is not used by any resource"}},
+    {"id":"undeclared-setter","shortDescription":{"text":"Setter used by resources is not declared in the Kptfile"}},
+    {"id":"conflicting-setter-values","shortDescription":{"text":"Fields parameterized by a scalar setter have conflicting values"}},
+    {"id":"setter-discovery/MalformedPattern","shortDescription":{"text":"Setter discovery warning MalformedPattern"}}]}},
+  "results":[
+    {"ruleId":"conflicting-setter-values","level":"warning",
+      "message":{"text":"setter \"app\" has conflicting values \"my-app\" in [test.yaml], \"other-app\" in [test.yaml]"},
+      "locations":[
+        {"physicalLocation":{"artifactLocation":{"uri":"test.yaml"},"region":{"startLine":4,"startColumn":9}}},
+        {"physicalLocation":{"artifactLocation":{"uri":"test.yaml"}}}]},
+    {"ruleId":"undeclared-setter","level":"error",
+      "message":{"text":"setter \"ns\" is used by resources but not declared in the Kptfile"},
+      "locations":[{"physicalLocation":{"artifactLocation":{"uri":"test.yaml"},"region":{"startLine":5,"startColumn":14}}}]},
+    {"ruleId":"unused-setter","level":"error",
+      "message":{"text":"setter \"tag\" is declared in the Kptfile but not used by any resource"},
+      "locations":[{"physicalLocation":{"artifactLocation":{"uri":"Kptfile"}}}]},
+    {"ruleId":"setter-discovery/MalformedPattern","level":"warning",
+      "message":{"text":"malformed setter pattern \"${owner\" of metadata.annotations.owner in test.yaml: setter reference \"${owner\" is not closed with \"}\""},
+      "locations":[{"physicalLocation":{"artifactLocation":{"uri":"test.yaml"}}}]}]}]}`, out[0])
+}
+
+func TestListSettersUpstream(t *testing.T) {
+	var tests = []struct {
+		name            string
+		resourceMap     map[string]string
+		expectedSources map[string]string
+		warnings        []*WarnSetterDiscovery
+	}{
+		{
+			name: "upstream in input",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: overlay
+upstream:
+  type: git
+  git:
+    repo: https://github.com/example/packages
+    directory: /base
+    ref: main
+upstreamLock:
+  type: git
+  git:
+    repo: https://github.com/example/packages
+    directory: /base
+    ref: main
+    commit: abc123
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: overlay-app
+`, "base/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: base
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: base-app
+        images: "[ubuntu]"
+        replicas: "3"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: overlay-app # kpt-set: ${app}
+`},
+			expectedSources: map[string]string{
+				"app":      "pipeline.mutators[0]",
+				"images":   "base/Kptfile pipeline.mutators[0]",
+				"replicas": "base/Kptfile pipeline.mutators[0]",
+			},
+		},
+		{
+			name: "root package named after its upstream",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: base
+upstream:
+  type: git
+  git:
+    repo: https://github.com/example/packages
+    directory: /base
+    ref: main
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+`, "base/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: base
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        foo: bar
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`},
+			expectedSources: map[string]string{
+				"app": "pipeline.mutators[0]",
+				"foo": "base/Kptfile pipeline.mutators[0]",
+			},
+		},
+		{
+			name: "root package named after its missing upstream",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: base
+upstream:
+  type: git
+  git:
+    repo: https://github.com/example/packages
+    directory: /base
+    ref: main
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    env: dev # kpt-set: ${env}
+`},
+			expectedSources: map[string]string{"app": "pipeline.mutators[0]", "env": ""},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: UnresolvedUpstream, message: "unable to find the Kptfile of the upstream package https://github.com/example/packages/base@main, setters [env] could not be resolved"},
+			},
+		},
+		{
+			name: "upstream not in input",
+			resourceMap: map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: overlay
+upstream:
+  type: git
+  git:
+    repo: https://github.com/example/packages
+    directory: /base
+    ref: v1.0
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: overlay-app
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: overlay-app # kpt-set: ${app}
+  labels:
+    env: dev # kpt-set: ${env}
+`},
+			expectedSources: map[string]string{"app": "pipeline.mutators[0]", "env": ""},
+			warnings: []*WarnSetterDiscovery{
+				{Reason: UnresolvedUpstream, message: "unable to find the Kptfile of the upstream package https://github.com/example/packages/base@v1.0, setters [env] could not be resolved"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkgDir := setupInputs(t, test.resourceMap)
+			defer os.RemoveAll(pkgDir)
+
+			ls := New()
+			err := kio.Pipeline{
+				Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+					MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+				Filters: []kio.Filter{&ls},
+			}.Execute()
+			require.NoError(t, err)
+			require.Equal(t, test.warnings, ls.Warnings)
+			sources := make(map[string]string)
+			for name, s := range ls.ScalarSetters {
+				sources[name] = s.Source
+			}
+			for name, s := range ls.ArraySetters {
+				sources[name] = s.Source
+			}
+			require.Equal(t, test.expectedSources, sources)
+		})
+	}
+}
+
+func TestArraySetterValueOrder(t *testing.T) {
+	inputs := []map[string]string{
+		{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        images: "[ubuntu, hbase]"
+`},
+		{"test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+`},
+	}
+	for _, preserveOrder := range []bool{false, true} {
+		t.Run(fmt.Sprintf("preserveOrder %t", preserveOrder), func(t *testing.T) {
+			var values []string
+			for _, input := range inputs {
+				pkgDir := setupInputs(t, input)
+				defer os.RemoveAll(pkgDir)
+
+				ls := New()
+				ls.PreserveOrder = preserveOrder
+				err := kio.Pipeline{
+					Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+						MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+					Filters: []kio.Filter{&ls},
+				}.Execute()
+				require.NoError(t, err)
+				results := ls.GetResults()
+				require.Len(t, results, 1)
+				values = append(values, results[0].Value)
+			}
+			expected := "[hbase, ubuntu]"
+			if preserveOrder {
+				expected = "[ubuntu, hbase]"
+			}
+			require.Equal(t, []string{expected, expected}, values)
+		})
+	}
+}
+
+func TestListSettersUntagged(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  app: my-app
+  replicas: "3"
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: my-app
+  annotations:
+    example: my-app # kpt-set: ${app} # list-setters:ignore
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    spec:
+      containers:
+        - name: my-app-sidecar
+          args: # kpt-set: ${args}
+            - my-app
+          ports:
+            - containerPort: 3
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.DetectUntagged = true
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*UntaggedField{
+		{File: "test.yaml", Path: "metadata.labels.app", Value: "my-app", Setters: []string{"app"}},
+		{File: "test.yaml", Path: "spec.selector.matchLabels.app", Value: "my-app", Setters: []string{"app"}},
+		{File: "test.yaml", Path: "spec.template.spec.containers[0].ports[0].containerPort", Value: "3", Setters: []string{"replicas"}},
+	}, ls.Untagged)
+	require.Equal(t, `heuristic: candidate unparameterized field metadata.labels.app in test.yaml has the value "my-app" of setters [app] `+
+		"but no setter comment, this may be a false positive", ls.Untagged[0].Error())
+}
+
+func TestListSettersAmbiguousPatterns(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: my-app-web # kpt-set: ${app}-${role}
+          image: gcr.io/my-project/nginx1.2 # kpt-set: gcr.io/${project}/${image}${tag}${suffix}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*AmbiguousPattern{
+		{File: "test.yaml", Path: "spec.template.spec.containers[0].image", Pattern: "gcr.io/${project}/${image}${tag}${suffix}",
+			Adjacent: []string{"${image}", "${tag}", "${suffix}"}},
+	}, ls.AmbiguousPatterns)
+}
+
+func TestAdjacentReferences(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "${a}-${b}"},
+		{pattern: "${a}${b}", expected: []string{"${a}", "${b}"}},
+		{pattern: "x${a}.${b}${c:-y}", expected: []string{"${b}", "${c:-y}"}},
+		{pattern: "${a}${b}-${c}${d}", expected: []string{"${a}", "${b}"}},
+		{pattern: "plain"},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require.Equal(t, test.expected, adjacentReferences(DollarBraceSyntax, test.pattern))
+		})
+	}
+}
+
+func TestListSettersVerify(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        replicas: "4"
+        zones: |
+          - us-east1-b
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: my-app-web # kpt-set: ${app}-${role}
+    full: my-appweb # kpt-set: ${app}${role}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.16 # kpt-set: nginx:${tag:-latest}
+      zones: # kpt-set: ${zones}
+        - us-east1-b
+        - us-east1-c
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Verify = true
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*Mismatch{
+		{File: "test.yaml", Path: "spec.replicas", Pattern: "${replicas}", Value: "3", Applied: "4"},
+		{File: "test.yaml", Path: "spec.template.spec.zones", Pattern: "${zones}", Value: "[us-east1-b, us-east1-c]", Applied: "[us-east1-b]"},
+	}, ls.Mismatches)
+	require.Equal(t, `re-applying the setters to spec.replicas in test.yaml would change "3" to "4", `+
+		`the setter pattern "${replicas}" isn't resolved consistently`, ls.Mismatches[0].Error())
+
+	ls = New()
+	ls.Verify = true
+	ls.ErrorOn = []string{MismatchCategory}
+	err = kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `found setter problems of the errorOn categories [mismatch]: `+
+		`re-applying the setters to spec.replicas in test.yaml would change "3" to "4"`)
+}
+
+func TestListSettersDebug(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  namespace: default # kpt-set:
+spec:
+  replicas: # kpt-set: ${replicas}
+    3
+  selector:
+    matchLabels:
+      app: my-app # kpt-set ${app}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.16 # kpt-set: gcr.io/${image}:${tag}
+          args: [a, b] # kpt-set: ${args}
+`, "service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Debug = true
+	ls.Kinds = []string{"Deployment"}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	var actual []string
+	for _, e := range ls.Trace {
+		actual = append(actual, e.String())
+	}
+	require.Equal(t, []string{
+		`File: service.yaml, resource Service/my-app is skipped as it doesn't match the kinds or changedFiles options`,
+		`File: test.yaml, Path: metadata.name, found setter pattern "${app}"`,
+		`File: test.yaml, Path: metadata.namespace, setter comment "# kpt-set:" has no setter pattern`,
+		`File: test.yaml, Path: spec.replicas, setter comment on the key of a scalar field is ignored, it must follow the value`,
+		`File: test.yaml, Path: spec.selector.matchLabels.app, comment "# kpt-set ${app}" doesn't start with the setter comment "# kpt-set: "`,
+		`File: test.yaml, Path: spec.template.spec.containers[0].args, found array setter pattern "${args}" on a flow style sequence`,
+		`File: test.yaml, Path: spec.template.spec.containers[0].image, found setter pattern "gcr.io/${image}:${tag}"`,
+		`File: test.yaml, Path: metadata.name, setter pattern "${app}" resolved app="my-app"`,
+		`File: test.yaml, Path: spec.template.spec.containers[0].args, array setter "args" resolved [a, b]`,
+		`File: test.yaml, Path: spec.template.spec.containers[0].image, value "nginx:1.16" doesn't match the setter pattern "gcr.io/${image}:${tag}"`,
+	}, actual)
+}
+
+func TestGetArraySetterValues(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "flow", value: "[a, b]", expected: []string{"a", "b"}},
+		{name: "irregular spacing", value: "[ a ,b ,  c d  ]", expected: []string{"a", "b", "c d"}},
+		{name: "line breaks", value: "[a,\n  b,\n  c]", expected: []string{"a", "b", "c"}},
+		{name: "quoted elements with commas", value: `["a, b", 'c,d', e]`, expected: []string{"a, b", "c,d", "e"}},
+		{name: "quoted elements keep spaces", value: `[" a ", b]`, expected: []string{" a ", "b"}},
+		{name: "block", value: "- a\n- b\n", expected: []string{"a", "b"}},
+		{name: "empty", value: "[]", expected: []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := getArraySetterValues(test.value)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+	_, err := getArraySetterValues("a, b")
+	require.Error(t, err)
+}
+
+func TestValidatePattern(t *testing.T) {
+	var tests = []struct {
+		name    string
+		syntax  SetterSyntax
+		pattern string
+		errMsg  string
+	}{
+		{name: "valid", syntax: DollarBraceSyntax, pattern: "${image}:${tag:-latest}"},
+		{name: "literal braces", syntax: DollarBraceSyntax, pattern: `{"name": "${app}"}`},
+		{name: "unclosed", syntax: DollarBraceSyntax, pattern: "${foo",
+			errMsg: `setter reference "${foo" is not closed with "}"`},
+		{name: "unclosed after a reference", syntax: DollarBraceSyntax, pattern: "${foo}-${bar",
+			errMsg: `setter reference "${bar" is not closed with "}"`},
+		{name: "nested unbalanced", syntax: DollarBraceSyntax, pattern: "${foo-${bar}",
+			errMsg: `setter reference "${bar}" is nested in "${foo-${bar}"`},
+		{name: "nested balanced", syntax: DollarBraceSyntax, pattern: "${foo-${bar}}",
+			errMsg: `setter reference "${bar}}" is nested in "${foo-${bar}}"`},
+		{name: "double brace unclosed", syntax: DoubleBraceSyntax, pattern: "{{foo}",
+			errMsg: `setter reference "{{foo}" is not closed with "}}"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.syntax.(PatternValidator).ValidatePattern(test.pattern)
+			if test.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.errMsg)
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	app := &Result{Name: "app", Value: "my-app", Type: "str", Count: 1}
+	images := &Result{Name: "images", Value: "[nginx, ubuntu]", Type: "array", Count: 2}
+	require.Equal(t, app.Fingerprint(), (&Result{Name: "app", Value: "my-app", Type: "str", Count: 1, Files: []string{"test.yaml"}}).Fingerprint())
+	require.NotEqual(t, app.Fingerprint(), (&Result{Name: "app", Value: "my-app", Type: "str", Count: 2}).Fingerprint())
+	require.NotEqual(t, app.Fingerprint(), (&Result{Name: "app", Value: "other-app", Type: "str", Count: 1}).Fingerprint())
+	require.NotEqual(t, app.Fingerprint(), (&Result{Name: "app", Value: "my-app", Type: "int", Count: 1}).Fingerprint())
+	require.Len(t, app.Fingerprint(), 64)
+
+	// the package fingerprint doesn't depend on the order of the results
+	require.Equal(t, Fingerprint([]*Result{app, images}), Fingerprint([]*Result{images, app}))
+	require.NotEqual(t, Fingerprint([]*Result{app, images}), Fingerprint([]*Result{app}))
+
+	ls := New()
+	ls.ScalarSetters["app"] = &ScalarSetter{Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 1}
+	before := ls.Fingerprint()
+	require.Equal(t, before, ls.Fingerprint())
+	ls.ScalarSetters["app"].Value = "other-app"
+	require.NotEqual(t, before, ls.Fingerprint())
+}
+
+func TestMerge(t *testing.T) {
+	run := func(resources map[string]string) ListSetters {
+		pkgDir := setupInputs(t, resources)
+		defer os.RemoveAll(pkgDir)
+		ls := New()
+		err := kio.Pipeline{
+			Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+			Filters: []kio.Filter{&ls},
+		}.Execute()
+		require.NoError(t, err)
+		return ls
+	}
+	first := run(map[string]string{"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: app
+          args: [--debug] # kpt-set: ${args}
+`})
+	second := run(map[string]string{"service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 5 # kpt-set: ${replicas}
+`, "job.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          args: [--debug, --verbose] # kpt-set: ${args}
+`})
+
+	merged := Merge(first, second)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 3, FieldCount: 3, ResourceCount: 3, Type: "str", ValueType: "string", Files: []string{"deployment.yaml", "job.yaml", "service.yaml"}},
+		{Name: "args", Value: "[--debug, --verbose]", Values: []string{"--debug", "--verbose"}, Count: 2, FieldCount: 2, ResourceCount: 2, Type: "array", Files: []string{"deployment.yaml", "job.yaml"}},
+		{Name: "replicas", Value: "3", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "int", ValueType: "int", Files: []string{"deployment.yaml", "service.yaml"}},
+	}, merged.GetResults())
+	var warnings []string
+	for _, w := range merged.Warnings {
+		warnings = append(warnings, w.Error())
+	}
+	require.Contains(t, warnings, `setter "replicas" has conflicting values "3" in [deployment.yaml], "5" in [service.yaml]`)
+
+	// the runs are not modified
+	require.Equal(t, 1, first.ScalarSetters["app"].Count)
+	require.Equal(t, []string{"--debug"}, first.ArraySetters["args"].Values)
+	require.Equal(t, New().ScalarSetters, Merge().ScalarSetters)
+}
+
+func TestMatchPath(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "**/test/**", name: "apps/test/deployment.yaml", expected: true},
+		{pattern: "**/test/**", name: "test/deployment.yaml", expected: true},
+		{pattern: "**/test/**", name: "apps/testdata/deployment.yaml", expected: false},
+		{pattern: "apps/**", name: "apps/base/deployment.yaml", expected: true},
+		{pattern: "apps/**", name: "deployment.yaml", expected: false},
+		{pattern: "*.yaml", name: "deployment.yaml", expected: true},
+		{pattern: "*.yaml", name: "apps/deployment.yaml", expected: false},
+		{pattern: "**/*.yaml", name: "deployment.yaml", expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, matchPath(test.pattern, test.name))
+		})
+	}
+}
+
+func TestListSettersInventory(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          args: [a, b] # kpt-set: ${args}
+`, "inventory.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setter-inventory
+data:
+  stale: value # kpt-set: ${stale}
+`})
+	defer os.RemoveAll(pkgDir)
+	nodes, err := (&kio.LocalPackageReader{PackagePath: pkgDir, MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}).Read()
+	require.NoError(t, err)
+
+	ls := New()
+	ls.InventoryPath = "./inventory.yaml"
+	out, err := ls.Filter(nodes)
+	require.NoError(t, err)
+	// the inventory of the input is replaced rather than scanned
+	require.Len(t, out, 3)
+	require.NotContains(t, ls.ScalarSetters, "stale")
+	inventory := out[2]
+	require.Equal(t, "inventory.yaml", inventory.GetAnnotations()[kioutil.PathAnnotation])
+	require.Equal(t, "true", inventory.GetAnnotations()["config.kubernetes.io/local-config"])
+	require.Equal(t, "ConfigMap", inventory.GetKind())
+	require.Equal(t, "setter-inventory", inventory.GetName())
+	require.Equal(t, map[string]string{"app": "my-app", "args": "[a, b]"}, inventory.GetDataMap())
+
+	ls = New()
+	ls.InventoryPath = "inventory.yaml"
+	ls.InventoryKind = SetterInventoryKind
+	out, err = ls.Filter(nodes)
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+	require.Equal(t, SetterInventoryAPIVersion, out[2].GetApiVersion())
+	require.Equal(t, SetterInventoryKind, out[2].GetKind())
+	spec, err := out[2].Pipe(yaml.Lookup("spec"))
+	require.NoError(t, err)
+	require.Equal(t, `setters:
+- count: 1
+  fieldCount: 1
+  files:
+  - test.yaml
+  name: app
+  resourceCount: 1
+  source: pipeline.mutators[0]
+  type: str
+  value: my-app
+  valueType: string
+- count: 1
+  fieldCount: 1
+  files:
+  - test.yaml
+  name: args
+  resourceCount: 1
+  type: array
+  value:
+  - a
+  - b
+`, spec.MustString())
+
+	ls = New()
+	ls.InventoryPath = "test.yaml"
+	_, err = ls.Filter(nodes)
+	require.EqualError(t, err, `inventoryPath "test.yaml" holds the resource Deployment/my-app which is not a setter inventory`)
+}
+
+func TestListSettersMaxDepth(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.16 # kpt-set: nginx:${tag}
+`, "service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.MaxDepth = 3
+	ls.Debug = true
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 2, FieldCount: 2, ResourceCount: 2, Type: "str", ValueType: "string", Files: []string{"service.yaml", "test.yaml"}},
+		{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"test.yaml"}},
+	}, ls.GetResults())
+	require.Equal(t, 1, ls.Stats.Truncated)
+	var truncated []string
+	for _, e := range ls.Trace {
+		if strings.Contains(e.Message, "nested deeper") {
+			truncated = append(truncated, e.String())
+		}
+	}
+	require.Equal(t, []string{
+		"File: test.yaml, Path: spec.template.spec.containers, field is nested deeper than maxDepth 3 and isn't visited",
+	}, truncated)
+}
+
+func TestListSettersAnchors(t *testing.T) {
+	node, err := yaml.Parse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  labels:
+    app: &app my-app # kpt-set: ${app}
+spec:
+  selector:
+    matchLabels:
+      app: *app
+  template:
+    spec:
+      containers:
+        - &container
+          name: app
+          image: nginx:1.16 # kpt-set: nginx:${tag}
+          resources:
+            limits: &limits
+              cpu: 500m # kpt-set: ${cpu}
+        - <<: *container
+          name: sidecar # kpt-set: ${sidecar}
+          resources:
+            requests: *limits
+`)
+	require.NoError(t, err)
+	require.NoError(t, node.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, "test.yaml")))
+
+	ls := New()
+	ls.Verbose = true
+	_, err = ls.Filter([]*yaml.RNode{node})
+	require.NoError(t, err)
+	var actual []string
+	for _, r := range ls.GetResults() {
+		paths := make([]string, len(r.Locations))
+		for i := range r.Locations {
+			paths[i] = r.Locations[i].Path
+		}
+		actual = append(actual, fmt.Sprintf("%s %d %s", r.Name, r.Count, strings.Join(paths, ",")))
+	}
+	require.Equal(t, []string{
+		"app 2 metadata.name,metadata.labels.app",
+		"cpu 1 spec.template.spec.containers[0].resources.limits.cpu",
+		"sidecar 1 spec.template.spec.containers[1].name",
+		"tag 1 spec.template.spec.containers[0].image",
+	}, actual)
+}
+
+func TestListSettersMetadata(t *testing.T) {
+	node, err := yaml.Parse(`apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: my-app.7d4b9 # kpt-set: ${app}.${hash}
+  labels:
+    app: my-app # kpt-set: ${app}
+    app.kubernetes.io/part-of: shop # kpt-set: ${system}
+  annotations:
+    owner: team-a@example.com # kpt-set: ${team}@example.com
+    config.kubernetes.io/local-config: "true"
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: my-app # kpt-set: ${app}
+      uid: 1234 # kpt-set: ${uid}
+spec:
+  template:
+    metadata:
+      labels:
+        app: my-app # kpt-set: ${app}
+`)
+	require.NoError(t, err)
+	require.NoError(t, node.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, "test.yaml")))
+
+	ls := New()
+	ls.Verbose = true
+	_, err = ls.Filter([]*yaml.RNode{node})
+	require.NoError(t, err)
+	var actual []string
+	for _, r := range ls.GetResults() {
+		paths := make([]string, len(r.Locations))
+		for i := range r.Locations {
+			paths[i] = r.Locations[i].Path
+		}
+		actual = append(actual, fmt.Sprintf("%s=%s %s", r.Name, r.Value, strings.Join(paths, ",")))
+	}
+	require.Equal(t, []string{
+		"app=my-app metadata.name,metadata.labels.app,metadata.ownerReferences[0].name,spec.template.metadata.labels.app",
+		"hash=7d4b9 metadata.name",
+		"system=shop metadata.labels.app.kubernetes.io/part-of",
+		"team=team-a metadata.annotations.owner",
+		"uid=1234 metadata.ownerReferences[0].uid",
+	}, actual)
+}
+
+func TestListSettersList(t *testing.T) {
+	node, err := yaml.Parse(`apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: my-app # kpt-set: ${app}
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: my-app # kpt-set: ${app}
+    spec:
+      replicas: 3 # kpt-set: ${replicas}
+`)
+	require.NoError(t, err)
+	require.NoError(t, node.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, "list.yaml")))
+	input, err := node.String()
+	require.NoError(t, err)
+
+	ls := New()
+	ls.Verbose = true
+	ls.Kinds = []string{"Deployment"}
+	out, err := ls.Filter([]*yaml.RNode{node})
+	require.NoError(t, err)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"list.yaml"},
+			Locations: []Location{{File: "list.yaml", Line: 11, Column: 13, Path: "metadata.name"}}, ValueBytes: 6},
+		{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"list.yaml"},
+			Locations: []Location{{File: "list.yaml", Line: 13, Column: 17, Path: "spec.replicas"}}, ValueBytes: 1},
+	}, ls.GetResults())
+	require.Len(t, out, 1)
+	output, err := out[0].String()
+	require.NoError(t, err)
+	require.Equal(t, input, output)
+
+	ls = New()
+	_, err = ls.Filter([]*yaml.RNode{node})
+	require.NoError(t, err)
+	require.Equal(t, 2, ls.GetResults()[0].ResourceCount)
+
+	crd, err := yaml.Parse(`apiVersion: networking.example.com/v1
+kind: IPAllowList
+metadata:
+  name: allow
+  labels:
+    env: dev # kpt-set: ${env}
+items: # kpt-set: ${cidrs}
+  - 10.0.0.0/8
+  - 192.168.0.0/16
+`)
+	require.NoError(t, err)
+	ls = New()
+	out, err = ls.Filter([]*yaml.RNode{crd})
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	var names []string
+	for _, r := range ls.GetResults() {
+		names = append(names, r.Name)
+	}
+	require.Equal(t, []string{"cidrs", "env"}, names)
+}
+
+func TestListSettersStats(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`, "sub/test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Kinds = []string{"Deployment"}
+	ls.ChangedFiles = []string{"test.yaml"}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir, MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, Stats{Documents: 4, Scanned: 1, Skipped: 3, KptfileFound: true}, ls.Stats)
+
+	// the stats are reset on each Filter call
+	_, err = ls.Filter(nil)
+	require.NoError(t, err)
+	require.Equal(t, Stats{}, ls.Stats)
+}
+
+func setupInputs(t *testing.T, resourceMap map[string]string) string {
+	t.Helper()
+	require := require.New(t)
+	baseDir, err := ioutil.TempDir("", "")
+	require.NoError(err)
+
+	for rpath, data := range resourceMap {
+		filePath := path.Join(baseDir, rpath)
+		err = os.MkdirAll(path.Dir(filePath), os.ModePerm)
+		require.NoError(err)
+		err = ioutil.WriteFile(path.Join(baseDir, rpath), []byte(data), 0644)
+		require.NoError(err)
+	}
+	return baseDir
+}
+
+func TestListPackage(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        tag: "1.0"
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.ReportUnused = true
+	var out bytes.Buffer
+	require.NoError(t, ls.ListPackage(pkgDir, &out))
+	require.Equal(t, "Name: app, Value: my-app, Type: str, Count: 1, Source: pipeline.mutators[0]\n"+
+		"Name: tag, Value: 1.0, Type: str, Count: 0, Source: pipeline.mutators[0]\n", out.String())
+
+	ls = New()
+	ls.DryRun = true
+	ls.Overrides = map[string]string{"app": "other-app"}
+	out.Reset()
+	require.NoError(t, ls.ListFrom(strings.NewReader(`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`), &out))
+	require.Equal(t, "File: , Path: metadata.name, Old: my-app, New: other-app\n", out.String())
+}
+
+func TestListSettersKptfileSources(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: base-app
+        images: "[ubuntu]"
+    - image: gcr.io/kpt-fn/set-labels:v0.1
+      configMap:
+        env: dev
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  app: overlay-app
+  replicas: "3"
+`, "test.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: overlay-app # kpt-set: ${app}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*WarnSetterDiscovery{{Reason: DuplicateSetters, message: "setters are declared by multiple apply-setters fns in Kptfile, the last declaration is used: " +
+		`app is declared as "base-app" in pipeline.mutators[0] and "overlay-app" in pipeline.mutators[2]`}}, ls.Warnings)
+	require.Equal(t, "overlay-app", ls.ScalarSetters["app"].Value)
+	require.Equal(t, "pipeline.mutators[2]", ls.ScalarSetters["app"].Source)
+	require.Equal(t, "pipeline.mutators[2]", ls.ScalarSetters["replicas"].Source)
+	require.Equal(t, "pipeline.mutators[0]", ls.ArraySetters["images"].Source)
+}
+
+func TestListSettersPerPackage(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: root
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: root-app
+`, "other/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: root-app # kpt-set: ${app}
+`, "sub/Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: sub
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: setters.yaml
+`, "sub/setters.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  app: sub-app
+  replicas: "3"
+`, "sub/deploy/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: sub-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.PerPackage = true
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile"), IncludeSubpackages: true}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Empty(t, ls.Warnings)
+	require.Empty(t, ls.GetResults())
+	require.Equal(t, []*PackageResult{
+		{Package: ".", Setters: []*Result{
+			{Name: "app", Value: "root-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"other/service.yaml"}, Source: "pipeline.mutators[0]"},
+		}},
+		{Package: "sub", Setters: []*Result{
+			{Name: "app", Value: "sub-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"sub/deploy/deployment.yaml"}, Source: "pipeline.mutators[0]"},
+			{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"sub/deploy/deployment.yaml"}, Source: "pipeline.mutators[0]"},
+		}},
+	}, ls.GetPackageResults())
+	require.Equal(t, Summary{Setters: 3, ScalarSetters: 3, Occurrences: 3}, ls.Summarize())
+}
+
+func TestListSettersConstraints(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"a.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  regions: # kpt-set: ${regions}
+    - us
+    - eu
+`, "b.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data:
+  regions: # kpt-set: ${regions}
+    - us
+    - ap
+    - sa
+  zones: # kpt-set: ${zones}
+    - ap-1
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Constraints = map[string]SetterConstraint{"regions": {Allowed: []string{"us", "eu"}}}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []*ConstraintViolation{
+		{Setter: "regions", File: "b.yaml", Disallowed: []string{"ap", "sa"}, Allowed: []string{"eu", "us"}},
+	}, ls.Violations)
+	require.Equal(t, `array setter "regions" in b.yaml has disallowed values [ap, sa], allowed values are [eu, us]`, ls.Violations[0].Error())
+
+	ls = New()
+	ls.Constraints = map[string]SetterConstraint{"regions": {Allowed: []string{"us", "eu"}}}
+	ls.ErrorOn = []string{ConstraintsCategory}
+	err = kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.EqualError(t, err, `found setter problems of the errorOn categories [constraints]: `+
+		`array setter "regions" in b.yaml has disallowed values [ap, sa], allowed values are [eu, us]`)
+}
+
+func TestGetResultsSortBy(t *testing.T) {
+	var tests = []struct {
+		name     string
+		sortBy   string
+		expected []string
+	}{
+		{
+			name:     "name",
+			sortBy:   NameSortOrder,
+			expected: []string{"app", "images", "ports", "replicas"},
+		},
+		{
+			name:     "type",
+			sortBy:   TypeSortOrder,
+			expected: []string{"app", "replicas", "images", "ports"},
+		},
+		{
+			name:     "count",
+			sortBy:   CountSortOrder,
+			expected: []string{"ports", "app", "images", "replicas"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := New()
+			ls.SortBy = test.sortBy
+			ls.ScalarSetters["app"] = &ScalarSetter{Name: "app", Value: "my-app", Type: "str", ValueType: "string", Count: 2}
+			ls.ScalarSetters["replicas"] = &ScalarSetter{Name: "replicas", Value: "3", Type: "int", ValueType: "int", Count: 1}
+			ls.ArraySetters["images"] = &ArraySetter{Name: "images", Values: []string{"ubuntu"}, Count: 2}
+			ls.ArraySetters["ports"] = &ArraySetter{Name: "ports", Values: []string{"80"}, Count: 5}
+			var actual []string
+			for _, r := range ls.GetResults() {
+				actual = append(actual, r.Name)
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
 
+func TestGetResultsSortByTies(t *testing.T) {
+	for _, sortBy := range sortOrders() {
+		t.Run(sortBy, func(t *testing.T) {
 			ls := New()
-			inout := &kio.LocalPackageReadWriter{
-				PackagePath:        pkgDir,
-				NoDeleteFiles:      true,
-				PackageFileName:    "Kptfile",
-				MatchFilesGlob:     append(kio.DefaultMatch, "Kptfile"),
-				IncludeSubpackages: true,
+			ls.SortBy = sortBy
+			for _, name := range []string{"zone", "app-name", "app", "tag", "app-tag"} {
+				ls.ScalarSetters[name] = &ScalarSetter{Name: name, Value: "v", Type: "str", Count: 2}
 			}
-			err := kio.Pipeline{
-				Inputs:  []kio.Reader{inout},
-				Filters: []kio.Filter{&ls},
-				Outputs: []kio.Writer{inout},
-			}.Execute()
-			if test.errMsg != "" {
-				require.NotNil(err)
-				require.Contains(err.Error(), test.errMsg)
-			} else {
-				require.NoError(err)
-				require.ElementsMatch(ls.Warnings, test.warnings)
-				actualResources := ls.GetResults()
-				require.ElementsMatch(actualResources, test.expectedResult)
+			ls.ArraySetters["app"] = &ArraySetter{Name: "app", Values: []string{"v"}, Count: 2}
+			var expected []string
+			for i := 0; i < 20; i++ {
+				var actual []string
+				for _, r := range ls.GetResults() {
+					actual = append(actual, r.Name+"/"+r.Type)
+				}
+				if expected == nil {
+					expected = actual
+				}
+				require.Equal(t, expected, actual)
 			}
-
+			if sortBy == TypeSortOrder {
+				require.Equal(t, []string{"app/str", "app-name/str", "app-tag/str", "tag/str", "zone/str", "app/array"}, expected)
+				return
+			}
+			require.Equal(t, []string{"app/array", "app/str", "app-name/str", "app-tag/str", "tag/str", "zone/str"}, expected)
 		})
 	}
 }
 
-func setupInputs(t *testing.T, resourceMap map[string]string) string {
-	t.Helper()
-	require := require.New(t)
-	baseDir, err := ioutil.TempDir("", "")
-	require.NoError(err)
+func TestDiscoverSetters(t *testing.T) {
+	node := yaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  images: # kpt-set: ${images}
+    - ubuntu
+`)
+	ls := New()
+	actual, err := ls.DiscoverSetters(node)
+	require.NoError(t, err)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"deploy.yaml"}},
+		{Name: "images", Value: "[ubuntu]", Values: []string{"ubuntu"}, Count: 1, FieldCount: 1, ResourceCount: 1, Type: "array", Files: []string{"deploy.yaml"}},
+		{Name: "replicas", Value: "3", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "int", ValueType: "int", Files: []string{"deploy.yaml"}},
+	}, actual)
+	require.Empty(t, ls.Warnings)
+}
 
-	for rpath, data := range resourceMap {
-		filePath := path.Join(baseDir, rpath)
-		err = os.MkdirAll(path.Dir(filePath), os.ModePerm)
-		require.NoError(err)
-		err = ioutil.WriteFile(path.Join(baseDir, rpath), []byte(data), 0644)
-		require.NoError(err)
+func TestListSettersLocationPath(t *testing.T) {
+	node := yaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  template:
+    spec:
+      containers:
+        - name: sidecar
+          image: envoy:1.0 # kpt-set: envoy:${envoy-tag}
+        - name: app
+          image: nginx:1.21 # kpt-set: nginx:${tag}
+          args: # kpt-set: ${args}
+            - --debug
+`)
+	ls := New()
+	ls.Verbose = true
+	actual, err := ls.DiscoverSetters(node)
+	require.NoError(t, err)
+	var paths []string
+	for _, r := range actual {
+		for _, l := range r.Locations {
+			paths = append(paths, l.Path)
+		}
 	}
-	return baseDir
+	require.Equal(t, []string{
+		"spec.template.spec.containers[1].args",
+		"spec.template.spec.containers[0].image",
+		"spec.template.spec.containers[1].image",
+	}, paths)
+}
+
+func TestListSettersWindowsPaths(t *testing.T) {
+	nodes := []*yaml.RNode{
+		yaml.MustParse(`apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configPath: 'config\setters.yaml'
+`),
+		yaml.MustParse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+data:
+  app: my-app
+`),
+		yaml.MustParse(`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+`),
+	}
+	for i, p := range []string{`pkg\Kptfile`, `pkg\config\setters.yaml`, `pkg\apps\service.yaml`} {
+		require.NoError(t, nodes[i].PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, p)))
+	}
+	ls := New()
+	_, err := ls.Filter(nodes)
+	require.NoError(t, err)
+	require.Empty(t, ls.Warnings)
+	require.Equal(t, []*Result{
+		{Name: "app", Value: "my-app", Count: 1, FieldCount: 1, ResourceCount: 1, Type: "str", ValueType: "string", Files: []string{"pkg/apps/service.yaml"}, Source: "pipeline.mutators[0]"},
+	}, ls.GetResults())
+}
+
+func TestListSnippet(t *testing.T) {
+	ls := New()
+	actual, err := ls.ListSnippet(strings.NewReader(`spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - image: nginx:1.21 # kpt-set: nginx:${tag}
+          args: # kpt-set: ${args}
+            - --debug
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Name: args, Value: [--debug], Type: array, Count: 1",
+		"Name: replicas, Value: 3, Type: int, Count: 1",
+		"Name: tag, Value: 1.21, Type: str, Count: 1",
+	}, actual)
+	require.Empty(t, ls.Warnings)
+	require.Empty(t, ls.GetResults()[0].Files)
+
+	ls = New()
+	_, err = ls.ListSnippet(strings.NewReader("a: b # kpt-set: ${a}\n---\nc: d # kpt-set: ${c}\n"))
+	require.EqualError(t, err, "expected a single YAML document in the snippet, found 2")
+}
+
+func TestListSettersDryRun(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9 # kpt-set: ${image}:${tag}
+        - name: sidecar
+          image: sidecar:${tag} # kpt-set: sidecar:${tag}
+  images: # kpt-set: ${images}
+    - ubuntu
+    - hbase
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.DryRun = true
+	ls.Overrides = map[string]string{"tag": "1.8.0", "replicas": "3", "images": "[alpine, hbase]"}
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{File: "test.yaml", Path: "spec.images", Old: "[ubuntu, hbase]", New: "[alpine, hbase]"},
+		{File: "test.yaml", Path: "spec.template.spec.containers[0].image", Old: "nginx:1.7.9", New: "nginx:1.8.0"},
+		{File: "test.yaml", Path: "spec.template.spec.containers[1].image", Old: "sidecar:${tag}", New: "sidecar:1.8.0"},
+	}, ls.Changes)
+	require.Equal(t, "File: test.yaml, Path: spec.images, Old: [ubuntu, hbase], New: [alpine, hbase]", ls.Changes[0].String())
+}
+
+func TestListSettersDryRunNamespaceSetter(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  namespace: dev # kpt-set: ${namespace}
+  labels:
+    tier: web # kpt-set: ${tier}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	fc, err := yaml.Parse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: list-setters-fn-config
+data:
+  dryRun: "true"
+  values: |
+    namespace: prod
+    tier: api
+`)
+	require.NoError(t, err)
+	ls := New()
+	require.NoError(t, Decode(fc, &ls))
+	require.Equal(t, "", ls.Namespace)
+	err = kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{File: "test.yaml", Path: "metadata.namespace", Old: "dev", New: "prod"},
+		{File: "test.yaml", Path: "metadata.labels.tier", Old: "web", New: "api"},
+	}, ls.Changes)
 }
 
 func TestCurrentSetterValues(t *testing.T) {
@@ -535,6 +3683,24 @@ func TestCurrentSetterValues(t *testing.T) {
 		pattern  string
 		expected map[string]string
 	}{
+		{
+			name:     "empty setter value",
+			value:    "my-app",
+			pattern:  `my-app${suffix}`,
+			expected: map[string]string{"suffix": ""},
+		},
+		{
+			name:     "empty setter value with other setters",
+			value:    "my-app.com",
+			pattern:  `${name}.${env}com`,
+			expected: map[string]string{"name": "my-app", "env": ""},
+		},
+		{
+			name:     "unmatched value",
+			value:    "other-app",
+			pattern:  `my-app${suffix}`,
+			expected: map[string]string{},
+		},
 		{
 			name:    "setter values from pattern 1",
 			value:   "foo-dev-bar-us-east-1-baz",
@@ -582,6 +3748,41 @@ func TestCurrentSetterValues(t *testing.T) {
 			pattern:  `${project-id}/${image}${tag}`,
 			expected: map[string]string{},
 		},
+		{
+			name:    "setter values with prefix and suffix",
+			value:   "prefix-x-y-z-suffix",
+			pattern: `prefix-${a}-${b}-suffix`,
+			expected: map[string]string{
+				"a": "x",
+				"b": "y-z",
+			},
+		},
+		{
+			name:     "adjacent setters are unresolved",
+			value:    "xy",
+			pattern:  `${a}${b}`,
+			expected: map[string]string{},
+		},
+		{
+			name:    "repeated setter with same values",
+			value:   "x.x",
+			pattern: `${a}.${a}`,
+			expected: map[string]string{
+				"a": "x",
+			},
+		},
+		{
+			name:     "setter with default value",
+			value:    "foo-1.0",
+			pattern:  `foo-${tag:-latest}`,
+			expected: map[string]string{"tag": "1.0"},
+		},
+		{
+			name:     "repeated setter with different values",
+			value:    "x.y",
+			pattern:  `${a}.${a}`,
+			expected: map[string]string{},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -590,3 +3791,439 @@ func TestCurrentSetterValues(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchSetterValuesOptions(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		pattern  string
+		opts     matchOptions
+		expected map[string]string
+	}{
+		{
+			name:     "unanchored matches part of the value",
+			value:    "prefix-my-app-layer.dev",
+			pattern:  `my-app-layer.${env}`,
+			expected: map[string]string{"env": "dev"},
+		},
+		{
+			name:     "anchored requires the whole value to match",
+			value:    "prefix-my-app-layer.dev",
+			pattern:  `my-app-layer.${env}`,
+			opts:     matchOptions{anchored: true},
+			expected: map[string]string{},
+		},
+		{
+			name:     "anchored with trailing literal",
+			value:    "my-app-layer.dev.example.com",
+			pattern:  `my-app-layer.${env}.example.com`,
+			opts:     matchOptions{anchored: true},
+			expected: map[string]string{"env": "dev"},
+		},
+		{
+			name:     "case-sensitive",
+			value:    "My-App-Layer.DEV",
+			pattern:  `my-app-layer.${env}`,
+			expected: map[string]string{},
+		},
+		{
+			name:     "case-insensitive",
+			value:    "My-App-Layer.DEV",
+			pattern:  `my-app-layer.${env}`,
+			opts:     matchOptions{anchored: true, ignoreCase: true},
+			expected: map[string]string{"env": "DEV"},
+		},
+		{
+			name:     "double brace syntax",
+			value:    "gcr.io/my-project/app:1.0",
+			pattern:  `gcr.io/{{project}}/app:{{tag}}`,
+			opts:     matchOptions{syntax: DoubleBraceSyntax},
+			expected: map[string]string{"project": "my-project", "tag": "1.0"},
+		},
+		{
+			name:     "double bracket syntax with special characters",
+			value:    "a.b[1]-dev",
+			pattern:  `a.b[1]-[[env]]`,
+			opts:     matchOptions{syntax: DoubleBracketSyntax, anchored: true},
+			expected: map[string]string{"env": "dev"},
+		},
+		{
+			name:     "other syntaxes are literal",
+			value:    "${env}-dev",
+			pattern:  `${env}-{{env}}`,
+			opts:     matchOptions{syntax: DoubleBraceSyntax, anchored: true},
+			expected: map[string]string{"env": "dev"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, _ := matchSetterValues(test.pattern, test.value, test.opts)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestMatcherCache(t *testing.T) {
+	ls := New()
+	m := ls.matcher(`${image}:${tag}`, matchOptions{})
+	require.NotNil(t, m)
+	require.Same(t, m, ls.matcher(`${image}:${tag}`, matchOptions{}))
+	require.NotSame(t, m, ls.matcher(`${image}:${tag}`, matchOptions{anchored: true}))
+	require.Nil(t, ls.matcher(`${image}${tag}`, matchOptions{}))
+}
+
+func TestListSettersFileAttribution(t *testing.T) {
+	input := map[string]string{"a.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config # kpt-set: ${app}-config
+data:
+  tag: "1.0" # kpt-set: ${tag}
+`, "b.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  ports: # kpt-set: ${ports}
+    - 80
+`}
+	pkgDir := setupInputs(t, input)
+	defer os.RemoveAll(pkgDir)
+	nodes, err := (&kio.LocalPackageReader{PackagePath: pkgDir}).Read()
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+	// interleave the resources of the files
+	order := map[string]int{"Deployment": 0, "Service": 1, "ConfigMap": 2}
+	sort.Slice(nodes, func(i, j int) bool { return order[nodes[i].GetKind()] < order[nodes[j].GetKind()] })
+	require.Equal(t, []string{"Deployment", "Service", "ConfigMap"}, []string{nodes[0].GetKind(), nodes[1].GetKind(), nodes[2].GetKind()})
+
+	ls := New()
+	for _, node := range nodes {
+		_, err := ls.DiscoverSetters(node)
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]int{"a.yaml": 2, "b.yaml": 1}, ls.ScalarSetters["app"].Files)
+	require.Equal(t, map[string]int{"a.yaml": 1}, ls.ScalarSetters["replicas"].Files)
+	require.Equal(t, map[string]int{"a.yaml": 1}, ls.ScalarSetters["tag"].Files)
+	require.Equal(t, map[string]int{"b.yaml": 1}, ls.ArraySetters["ports"].Files)
+	var files []string
+	for _, loc := range ls.ScalarSetters["app"].Locations {
+		files = append(files, loc.File)
+	}
+	require.Equal(t, []string{"a.yaml", "b.yaml", "a.yaml"}, files)
+
+	filtered := New()
+	_, err = filtered.Filter(nodes)
+	require.NoError(t, err)
+	require.Equal(t, ls.ScalarSetters["app"].Files, filtered.ScalarSetters["app"].Files)
+	require.Equal(t, ls.ArraySetters["ports"].Files, filtered.ArraySetters["ports"].Files)
+}
+
+// largePackage returns n Deployments parameterized by setters, in separate files
+func largePackage(n int) []*yaml.RNode {
+	var nodes []*yaml.RNode
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, yaml.MustParse(fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-%d
+  namespace: my-project-dev # kpt-set: ${project}-${env}
+  annotations:
+    config.kubernetes.io/path: deployment-%d.yaml
+spec:
+  replicas: %d # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: gcr.io/my-project/app:1.0.0 # kpt-set: gcr.io/${project}/app:${tag}
+          args: # kpt-set: ${args}
+            - --debug
+`, i, i, 3+i%2)))
+	}
+	return nodes
+}
+
+func BenchmarkFilterLargePackage(b *testing.B) {
+	nodes := largePackage(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ls := New()
+		if _, err := ls.Filter(nodes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilterLargePackageWorkers(b *testing.B) {
+	nodes := largePackage(1000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ls := New()
+				ls.Workers = workers
+				if _, err := ls.Filter(nodes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFilterLargePackageCountMode(b *testing.B) {
+	nodes := largePackage(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ls := New()
+		ls.Mode = CountMode
+		if _, err := ls.Filter(nodes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestListSettersResolveMode(t *testing.T) {
+	pkgDir := setupInputs(t, map[string]string{"Kptfile": `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: test
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.2
+      configMap:
+        app: my-app
+        image: gcr.io/${project}/nginx
+        project: ${project}
+        zones: |
+          - us-east1-b
+          - ${zone}
+`, "test.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: gcr.io/${project}/nginx # kpt-set: ${image}
+`})
+	defer os.RemoveAll(pkgDir)
+
+	ls := New()
+	ls.Mode = ResolveMode
+	err := kio.Pipeline{
+		Inputs: []kio.Reader{&kio.LocalPackageReader{PackagePath: pkgDir,
+			MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{&ls},
+	}.Execute()
+	require.NoError(t, err)
+	actual, err := ls.FormatResults()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Name: app, Value: my-app, Resolved: true",
+		"Name: image, Value: gcr.io/${project}/nginx, Resolved: false",
+		"Name: project, Value: ${project}, Resolved: false",
+		"Name: zones, Value: [${zone}, us-east1-b], Resolved: false",
+	}, actual)
+
+	ls.OutputFormat = JSONOutputFormat
+	actual, err = ls.FormatResults()
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"name":"app","value":"my-app","resolved":true},`+
+		`{"name":"image","value":"gcr.io/${project}/nginx","resolved":false},`+
+		`{"name":"project","value":"${project}","resolved":false},`+
+		`{"name":"zones","value":"[${zone}, us-east1-b]","resolved":false}]`, actual[0])
+}
+
+func TestListSettersCountMode(t *testing.T) {
+	nodes := largePackage(10)
+	full := New()
+	_, err := full.Filter(nodes)
+	require.NoError(t, err)
+
+	ls := New()
+	ls.Mode = CountMode
+	_, err = ls.Filter(nodes)
+	require.NoError(t, err)
+	var expected []string
+	for _, r := range full.GetResults() {
+		expected = append(expected, fmt.Sprintf("Name: %s, Count: %d", r.Name, r.Count))
+	}
+	actual, err := ls.FormatResults()
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+	require.Equal(t, []string{
+		"Name: args, Count: 10",
+		"Name: env, Count: 10",
+		"Name: project, Count: 20",
+		"Name: replicas, Count: 10",
+		"Name: tag, Count: 10",
+	}, actual)
+
+	ls.OutputFormat = JSONOutputFormat
+	actual, err = ls.FormatResults()
+	require.NoError(t, err)
+	require.Equal(t, []string{`[{"name":"args","count":10},{"name":"env","count":10},{"name":"project","count":20},` +
+		`{"name":"replicas","count":10},{"name":"tag","count":10}]`}, actual)
+}
+
+func TestListSettersWorkers(t *testing.T) {
+	nodes := largePackage(50)
+	sequential := New()
+	sequential.Workers = 1
+	sequential.Verbose = true
+	_, err := sequential.Filter(nodes)
+	require.NoError(t, err)
+	for _, workers := range []int{0, 4, 100} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			ls := New()
+			ls.Workers = workers
+			ls.Verbose = true
+			_, err := ls.Filter(nodes)
+			require.NoError(t, err)
+			require.Equal(t, sequential.GetResults(), ls.GetResults())
+			require.Equal(t, sequential.Warnings, ls.Warnings)
+		})
+	}
+}
+
+// cancelingSyntax cancels the discovery when it's first used to find setter references
+type cancelingSyntax struct {
+	SetterSyntax
+	cancel context.CancelFunc
+}
+
+func (s cancelingSyntax) FindReferences(pattern string) [][]int {
+	s.cancel()
+	return s.SetterSyntax.FindReferences(pattern)
+}
+
+func TestListSettersFilterWithContext(t *testing.T) {
+	nodes := largePackage(50)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ls := New()
+	ls.Workers = 1
+	ls.Syntax = cancelingSyntax{SetterSyntax: DollarBraceSyntax, cancel: cancel}
+	_, err := ls.FilterWithContext(ctx, nodes)
+	require.Equal(t, context.Canceled, err)
+	require.Less(t, ls.Stats.Scanned, len(nodes))
+	require.Empty(t, ls.GetResults())
+
+	ls = New()
+	ls.PerPackage = true
+	_, err = ls.FilterWithContext(ctx, nodes)
+	require.Equal(t, context.Canceled, err)
+	require.Empty(t, ls.Packages)
+}
+
+func TestParseReference(t *testing.T) {
+	var tests = []struct {
+		syntax      SetterSyntax
+		input       string
+		expected    string
+		expectedDef string
+	}{
+		{syntax: DollarBraceSyntax, input: "${tag}", expected: "tag"},
+		{syntax: DollarBraceSyntax, input: "${tag:-latest}", expected: "tag", expectedDef: "latest"},
+		{syntax: DollarBraceSyntax, input: "${url:-http://example.com:-1}", expected: "url", expectedDef: "http://example.com:-1"},
+		{syntax: DollarBraceSyntax, input: "${tag:-}", expected: "tag"},
+		{syntax: DoubleBracketSyntax, input: "[[tag:-latest]]", expected: "tag", expectedDef: "latest"},
+		{syntax: DoubleBraceSyntax, input: "{{tag}}", expected: "tag"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			name, def := test.syntax.ParseReference(test.input)
+			require.Equal(t, test.expected, name)
+			require.Equal(t, test.expectedDef, def)
+		})
+	}
+}
+
+func TestInferValueType(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{input: "3", expected: IntValueType},
+		{input: "0x1F", expected: IntValueType},
+		{input: "true", expected: BoolValueType},
+		{input: "False", expected: BoolValueType},
+		{input: "1.5", expected: FloatValueType},
+		{input: "1e3", expected: FloatValueType},
+		{input: "my-app", expected: StringValueType},
+		{input: "1.16.1", expected: StringValueType},
+		{input: "yes", expected: StringValueType},
+		{input: "null", expected: NullValueType},
+		{input: "~", expected: NullValueType},
+		{input: "", expected: StringValueType},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require.Equal(t, test.expected, inferValueType(test.input))
+		})
+	}
+}
+
+func TestExtractSetterPattern(t *testing.T) {
+	var tests = []struct {
+		name     string
+		comment  string
+		expected string
+	}{
+		{name: "setter", comment: "# kpt-set: ${foo}", expected: "${foo}"},
+		{name: "not a setter", comment: "# some comment", expected: ""},
+		{name: "trailing description", comment: "# kpt-set: ${foo} (legacy)", expected: "${foo}"},
+		{name: "trailing literal", comment: "# kpt-set: nginx:${tag}-alpine deprecated", expected: "nginx:${tag}-alpine"},
+		{name: "setters separated by spaces", comment: "# kpt-set: ${first} ${last}", expected: "${first} ${last}"},
+		{name: "setters separated by spaces with description", comment: "# kpt-set: ${first} ${last}  full name", expected: "${first} ${last}"},
+		{name: "without setters", comment: "# kpt-set: foo bar", expected: "foo bar"},
+		{name: "without spaces", comment: "#kpt-set:${foo}", expected: "${foo}"},
+		{name: "without space after hash", comment: "#kpt-set: ${foo}", expected: "${foo}"},
+		{name: "without space after identifier", comment: "# kpt-set:${foo}", expected: "${foo}"},
+		{name: "extra spaces", comment: "#  kpt-set:   ${foo}", expected: "${foo}"},
+		{name: "tabs", comment: "#\tkpt-set:\t${foo}", expected: "${foo}"},
+		{name: "trailing tab", comment: "# kpt-set: ${foo}\t(legacy)", expected: "${foo}"},
+		{name: "other identifier", comment: "# kpt-setter: ${foo}", expected: ""},
+		{name: "identifier in description", comment: "# see kpt-set: ${foo}", expected: ""},
+		{name: "ignore directive", comment: "# kpt-set: ${foo} # list-setters:ignore", expected: ""},
+		{name: "ignore directive without space", comment: "# kpt-set: ${foo} #list-setters:ignore", expected: ""},
+		{name: "ignore directive in description", comment: "# kpt-set: ${foo} see list-setters:ignore", expected: "${foo}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, extractSetterPattern(test.comment, SetterCommentIdentifier, nil))
+		})
+	}
+}
+
+func TestTrimSetterComment(t *testing.T) {
+	var tests = []struct {
+		name       string
+		comment    string
+		identifier string
+		expected   string
+		ok         bool
+	}{
+		{name: "default", comment: "#kpt-set: ${foo}", expected: "${foo}", ok: true},
+		{name: "custom", comment: "#\tset ${foo}", identifier: "# set ", expected: "${foo}", ok: true},
+		{name: "custom without separator", comment: "# setter ${foo}", identifier: "# set "},
+		{name: "custom without setters", comment: "# set", identifier: "# set ", ok: true},
+		{name: "not a comment", comment: "kpt-set: ${foo}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := trimSetterComment(test.comment, test.identifier)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package listsetters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// expandLists replaces the nodes of the List kinds e.g. v1/List or
+// ConfigMapList with the resources of their items, so that the setters are
+// attributed to the wrapped resources rather than to the List. The items are
+// copies annotated with the path, the document index and the offsets of their
+// List, the nodes aren't modified.
+func expandLists(nodes []*yaml.RNode) []*yaml.RNode {
+	var out []*yaml.RNode
+	for _, node := range nodes {
+		items := listItems(node)
+		if items == nil {
+			out = append(out, node)
+			continue
+		}
+		np, index, _ := kioutil.GetFileAnnotations(node)
+		line, column := offsets(node)
+		offset := fmt.Sprintf("%d:%d", line, column)
+		for _, item := range items {
+			item = item.Copy()
+			if np != "" && item.GetAnnotations()[kioutil.PathAnnotation] == "" {
+				if err := item.PipeE(yaml.SetAnnotation(kioutil.PathAnnotation, np)); err != nil {
+					continue
+				}
+			}
+			if index != "" && item.GetAnnotations()[kioutil.IndexAnnotation] == "" {
+				if err := item.PipeE(yaml.SetAnnotation(kioutil.IndexAnnotation, index)); err != nil {
+					continue
+				}
+			}
+			if err := item.PipeE(yaml.SetAnnotation(offsetAnnotation, offset)); err != nil {
+				continue
+			}
+			out = append(out, expandLists([]*yaml.RNode{item})...)
+		}
+	}
+	return out
+}
+
+// listItems returns the resources of the items of the node if it's a v1/List
+// or a List kind e.g. ConfigMapList whose items are all resources with an
+// apiVersion and a kind, nil otherwise. Items of a v1/List which aren't
+// mappings are dropped, nil is returned if no item is left so that the
+// node is kept as-is.
+func listItems(node *yaml.RNode) []*yaml.RNode {
+	if !strings.HasSuffix(node.GetKind(), "List") {
+		return nil
+	}
+	items, err := node.Pipe(yaml.Lookup("items"))
+	if err != nil || items == nil || items.YNode().Kind != yaml.SequenceNode {
+		return nil
+	}
+	v1List := node.GetKind() == "List" && node.GetApiVersion() == "v1"
+	var out []*yaml.RNode
+	for _, item := range items.Content() {
+		if item.Kind != yaml.MappingNode {
+			if v1List {
+				continue
+			}
+			return nil
+		}
+		rn := yaml.NewRNode(item)
+		if !v1List && (rn.GetApiVersion() == "" || rn.GetKind() == "") {
+			return nil
+		}
+		out = append(out, rn)
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package listsetters
+
+import (
+	"sort"
+)
+
+// Merge combines the results of several runs, e.g. of the chunks of a package
+// processed separately, into one. The options are taken from the first run.
+// The counts, files, resources and locations of the setters are summed, the
+// values of array setters are unioned in order, and the values of scalar
+// setters are reconciled: a setter used with different values in the runs
+// gets a conflicting values warning. The runs are not modified.
+func Merge(runs ...ListSetters) ListSetters {
+	if len(runs) == 0 {
+		return New()
+	}
+	out := *runs[0].packageSetters()
+	out.PerPackage = runs[0].PerPackage
+	out.Strict = runs[0].Strict
+	conflicts := make(map[string]bool)
+	for i := range runs {
+		run := &runs[i]
+		for name, s := range run.ScalarSetters {
+			if out.mergeScalarSetter(s) {
+				conflicts[name] = true
+			}
+		}
+		for _, s := range run.ArraySetters {
+			out.mergeArraySetter(s)
+		}
+		for name, value := range run.kfSetters {
+			if out.kfSetters == nil {
+				out.kfSetters = make(map[string]string)
+			}
+			if _, ok := out.kfSetters[name]; !ok {
+				out.kfSetters[name] = value
+			}
+		}
+		out.Warnings = append(out.Warnings, run.Warnings...)
+		out.Changes = append(out.Changes, run.Changes...)
+		out.Violations = append(out.Violations, run.Violations...)
+		out.Mismatches = append(out.Mismatches, run.Mismatches...)
+		out.Untagged = append(out.Untagged, run.Untagged...)
+		out.AmbiguousPatterns = append(out.AmbiguousPatterns, run.AmbiguousPatterns...)
+		out.Trace = append(out.Trace, run.Trace...)
+		out.Packages = append(out.Packages, run.Packages...)
+		out.Stats.add(run.Stats)
+	}
+
+	var names []string
+	for name := range conflicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out.Warnings = append(out.Warnings, conflictWarning(out.ScalarSetters[name]))
+	}
+	return out
+}
+
+// mergeScalarSetter merges s into the scalar setter of the same name, it
+// returns true if both are used with different values
+func (ls *ListSetters) mergeScalarSetter(s *ScalarSetter) bool {
+	existing, ok := ls.ScalarSetters[s.Name]
+	if !ok {
+		ls.ScalarSetters[s.Name] = s.clone()
+		return false
+	}
+	conflict := existing.Count > 0 && s.Count > 0 && existing.Value != s.Value
+	if existing.Count == 0 && s.Count > 0 {
+		// the value of a used setter is more accurate than a declared one
+		existing.Value = s.Value
+		existing.AppliedValue = s.AppliedValue
+		existing.ValueType = s.ValueType
+	}
+	if existing.Type == ScalarSetterDefaultType && s.Type != ScalarSetterDefaultType {
+		existing.Type = s.Type
+	}
+	if existing.Source == "" {
+		existing.Source = s.Source
+		existing.DeclaredValue = s.DeclaredValue
+	}
+	if existing.Default == "" {
+		existing.Default = s.Default
+	}
+	existing.Count += s.Count
+	mergeCounts(existing.Files, s.Files)
+	mergeCounts(existing.Resources, s.Resources)
+	existing.Locations = append(existing.Locations, s.Locations...)
+	for value, files := range s.DistinctValues {
+		for _, f := range files {
+			existing.addDistinctValue(value, f)
+		}
+	}
+	for doc, fields := range s.documentFields {
+		for _, f := range fields {
+			existing.addFieldValue(doc, f.Path, f.Value)
+		}
+	}
+	existing.Inconsistencies = append(existing.Inconsistencies, s.Inconsistencies...)
+	existing.Empty = existing.Empty || s.Empty
+	existing.Overridden = existing.Overridden || s.Overridden
+	return conflict
+}
+
+// mergeArraySetter merges s into the array setter of the same name
+func (ls *ListSetters) mergeArraySetter(s *ArraySetter) {
+	existing, ok := ls.ArraySetters[s.Name]
+	if !ok {
+		ls.ArraySetters[s.Name] = s.clone()
+		return
+	}
+	seen := make(map[string]bool)
+	for _, v := range existing.Values {
+		seen[v] = true
+	}
+	for _, v := range s.Values {
+		if !seen[v] {
+			seen[v] = true
+			existing.Values = append(existing.Values, v)
+		}
+	}
+	if existing.Source == "" {
+		existing.Source = s.Source
+	}
+	existing.Count += s.Count
+	mergeCounts(existing.Files, s.Files)
+	mergeCounts(existing.Resources, s.Resources)
+	existing.Locations = append(existing.Locations, s.Locations...)
+}
+
+// clone returns a copy of the setter which doesn't share its maps or slices
+func (s *ScalarSetter) clone() *ScalarSetter {
+	c := *s
+	c.Files = make(map[string]int)
+	mergeCounts(c.Files, s.Files)
+	c.Resources = make(map[string]int)
+	mergeCounts(c.Resources, s.Resources)
+	c.Locations = append([]Location(nil), s.Locations...)
+	c.Inconsistencies = append([]Inconsistency(nil), s.Inconsistencies...)
+	c.DistinctValues = nil
+	for value, files := range s.DistinctValues {
+		for _, f := range files {
+			c.addDistinctValue(value, f)
+		}
+	}
+	c.documentFields = nil
+	for doc, fields := range s.documentFields {
+		for _, f := range fields {
+			c.addFieldValue(doc, f.Path, f.Value)
+		}
+	}
+	return &c
+}
+
+// clone returns a copy of the setter which doesn't share its maps or slices
+func (s *ArraySetter) clone() *ArraySetter {
+	c := *s
+	c.Values = append([]string(nil), s.Values...)
+	c.Files = make(map[string]int)
+	mergeCounts(c.Files, s.Files)
+	c.Resources = make(map[string]int)
+	mergeCounts(c.Resources, s.Resources)
+	c.Locations = append([]Location(nil), s.Locations...)
+	return &c
+}
+
+// mergeCounts adds the counts of src to dst
+func mergeCounts(dst, src map[string]int) {
+	for k, v := range src {
+		dst[k] += v
+	}
+}
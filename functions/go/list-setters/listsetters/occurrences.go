@@ -0,0 +1,49 @@
+package listsetters
+
+import (
+	"sort"
+)
+
+// occurrences splits the results into a result per field parameterized by
+// the setter, sorted by file and then by field path, for the LocationSortOrder.
+// Each occurrence has a count of 1 and its Locations hold the location of the
+// field. The results without locations e.g. unused setters are listed first.
+func occurrences(rs []*Result) []*Result {
+	var out []*Result
+	for _, r := range rs {
+		if len(r.Locations) == 0 {
+			out = append(out, r)
+			continue
+		}
+		for _, loc := range r.Locations {
+			o := *r
+			o.Count, o.FieldCount, o.ResourceCount = 1, 1, 1
+			o.Files = []string{loc.File}
+			o.Locations = []Location{loc}
+			if r.ValueBytes > 0 {
+				o.ValueBytes = valueBytes(&o)
+			}
+			out = append(out, &o)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := firstLocation(out[i]), firstLocation(out[j])
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// firstLocation returns the first location of the result, the zero
+// Location if it has none
+func firstLocation(r *Result) Location {
+	if len(r.Locations) == 0 {
+		return Location{}
+	}
+	return r.Locations[0]
+}
@@ -0,0 +1,34 @@
+package listsetters
+
+import (
+	"fmt"
+)
+
+// formatOneline renders the setters listed by GetResults as a single line
+// e.g. "12 setters (9 scalar, 3 array), 2 unused, 1 undeclared", the setters
+// of all the packages are counted if PerPackage is set. The line always has
+// the same fields, so that it can be parsed by status checks.
+func (ls *ListSetters) formatOneline() string {
+	s := ls.Summarize()
+	unused, undeclared := ls.countStatuses()
+	for _, p := range ls.Packages {
+		u, d := p.Setters.countStatuses()
+		unused, undeclared = unused+u, undeclared+d
+	}
+	return fmt.Sprintf("%d setters (%d scalar, %d array), %d unused, %d undeclared",
+		s.Setters, s.ScalarSetters, s.ArraySetters, unused, undeclared)
+}
+
+// countStatuses returns the number of unused and undeclared setters listed by
+// GetResults, regardless of the ReportUnused and ReportUndeclared options
+func (ls *ListSetters) countStatuses() (int, int) {
+	unused, undeclared := 0, 0
+	for _, r := range ls.GetResults() {
+		if ls.unused(r) {
+			unused++
+		} else if ls.undeclared(r) {
+			undeclared++
+		}
+	}
+	return unused, undeclared
+}
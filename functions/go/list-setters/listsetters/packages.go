@@ -0,0 +1,130 @@
+package listsetters
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PackageSetters holds the setters discovered in a package
+type PackageSetters struct {
+	// Path is the directory of the Kptfile of the package,
+	// "." for the root of the input
+	Path string
+
+	// Setters holds the setters discovered in the package
+	Setters *ListSetters
+}
+
+// PackageResult represents the results of setter discovery in a package
+type PackageResult struct {
+	// Package is the directory of the Kptfile of the package
+	Package string `json:"package"`
+
+	// Setters are the results of the setters discovered in the package
+	Setters []*Result `json:"setters"`
+}
+
+// GetPackageResults returns the results of each package sorted by package path
+func (ls *ListSetters) GetPackageResults() []*PackageResult {
+	var out []*PackageResult
+	for _, p := range ls.Packages {
+		out = append(out, &PackageResult{Package: p.Path, Setters: p.Setters.GetResults()})
+	}
+	return out
+}
+
+// filterPackages discovers the setters of each package separately. Each
+// resource is attributed to the package of the closest Kptfile in its
+// directory or parent directories, resources outside of any package are
+// attributed to the root of the input.
+func (ls *ListSetters) filterPackages(ctx context.Context, nodes []*yaml.RNode) error {
+	pkgNodes := make(map[string][]*yaml.RNode)
+	var pkgDirs []string
+	for _, node := range nodes {
+		np := nodePath(node)
+		if path.Base(np) == kptfilev1.KptFileName {
+			pkgDirs = append(pkgDirs, path.Dir(path.Clean(np)))
+		}
+	}
+	for _, node := range nodes {
+		dir := packageDir(pkgDirs, nodePath(node))
+		pkgNodes[dir] = append(pkgNodes[dir], node)
+	}
+
+	var dirs []string
+	for dir := range pkgNodes {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	ls.Packages = nil
+	for _, dir := range dirs {
+		pkg := ls.packageSetters()
+		if _, err := pkg.FilterWithContext(ctx, pkgNodes[dir]); err != nil {
+			return err
+		}
+		for _, w := range pkg.Warnings {
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: w.Reason, message: fmt.Sprintf("package %s: %s", dir, w.Error()),
+				File: w.File, Path: w.Path})
+		}
+		ls.Changes = append(ls.Changes, pkg.Changes...)
+		ls.Violations = append(ls.Violations, pkg.Violations...)
+		ls.Mismatches = append(ls.Mismatches, pkg.Mismatches...)
+		ls.Untagged = append(ls.Untagged, pkg.Untagged...)
+		ls.AmbiguousPatterns = append(ls.AmbiguousPatterns, pkg.AmbiguousPatterns...)
+		ls.Trace = append(ls.Trace, pkg.Trace...)
+		ls.Stats.add(pkg.Stats)
+		ls.Packages = append(ls.Packages, &PackageSetters{Path: dir, Setters: pkg})
+	}
+	return nil
+}
+
+// packageSetters returns a ListSetters with the options of ls
+// to discover the setters of a package
+func (ls *ListSetters) packageSetters() *ListSetters {
+	pkg := *ls
+	pkg.ScalarSetters = make(map[string]*ScalarSetter)
+	pkg.ArraySetters = make(map[string]*ArraySetter)
+	pkg.Warnings = nil
+	pkg.Changes = nil
+	pkg.Violations = nil
+	pkg.Mismatches = nil
+	pkg.verified = nil
+	pkg.Untagged = nil
+	pkg.AmbiguousPatterns = nil
+	pkg.Trace = nil
+	pkg.Stats = Stats{}
+	pkg.Packages = nil
+	pkg.PerPackage = false
+	// the inventory of all the packages is appended once
+	pkg.InventoryPath = ""
+	// warnings of the packages are checked once all the packages are visited
+	pkg.Strict = false
+	pkg.ErrorOn = nil
+	// the warnings of all the packages are capped once
+	pkg.MaxWarnings = 0
+	pkg.kfSetters = nil
+	pkg.unresolvedUpstream = ""
+	return &pkg
+}
+
+// packageDir returns the closest directory of pkgDirs containing the file
+// at filePath, "." is returned if the file is not in any of pkgDirs
+func packageDir(pkgDirs []string, filePath string) string {
+	out := "."
+	dir := path.Dir(path.Clean(filePath))
+	for _, pkgDir := range pkgDirs {
+		if pkgDir == "." || !(pkgDir == dir || strings.HasPrefix(dir, pkgDir+"/")) {
+			continue
+		}
+		if out == "." || len(pkgDir) > len(out) {
+			out = pkgDir
+		}
+	}
+	return out
+}
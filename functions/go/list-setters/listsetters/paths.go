@@ -0,0 +1,105 @@
+package listsetters
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// slashPath converts the backslash separators of a path authored
+// on Windows to forward slashes, e.g. apps\deploy.yaml
+func slashPath(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// nodePath returns the path annotation of the node with forward slashes
+func nodePath(node *yaml.RNode) string {
+	return slashPath(node.GetAnnotations()[kioutil.PathAnnotation])
+}
+
+// splitPaths returns the cleaned paths of a comma or newline separated list
+func splitPaths(s string) []string {
+	var out []string
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, path.Clean(p))
+		}
+	}
+	return out
+}
+
+// validatePathPatterns returns an error if any of the patterns is malformed
+func validatePathPatterns(key string, patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return errors.Errorf("invalid %s pattern %q: %v", key, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchPath returns true if the slash separated name matches the pattern.
+// The pattern segments are matched with path.Match, and a "**" segment
+// matches zero or more segments, e.g. "**/test/**" matches "a/test/b.yaml".
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments implements matchPath on the path segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// matchesPaths returns true if the file of the resource node matches
+// one of the IncludePaths, or IncludePaths is empty, and doesn't match
+// any of the ExcludePaths
+func (ls *ListSetters) matchesPaths(node *yaml.RNode) bool {
+	np := path.Clean(nodePath(node))
+	for _, pattern := range ls.ExcludePaths {
+		if matchPath(pattern, np) {
+			return false
+		}
+	}
+	if len(ls.IncludePaths) == 0 {
+		return true
+	}
+	for _, pattern := range ls.IncludePaths {
+		if matchPath(pattern, np) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathsSkipReason returns why the node is skipped by the IncludePaths
+// or ExcludePaths, it returns an empty string if the node matches them
+func (ls *ListSetters) pathsSkipReason(node *yaml.RNode) string {
+	if ls.matchesPaths(node) {
+		return ""
+	}
+	return fmt.Sprintf("resource %s/%s is skipped as its file doesn't match the %s or matches the %s options",
+		node.GetKind(), node.GetName(), IncludePathsKey, ExcludePathsKey)
+}
@@ -0,0 +1,76 @@
+package listsetters
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// ListSetters is a kio.Filter which can be embedded as a stage of a
+// kio.Pipeline, the resources are passed through unchanged, except for the
+// inventory resource appended if InventoryPath is set, and the setters are
+// discovered into ls, e.g.
+//
+//	ls := listsetters.New()
+//	err := kio.Pipeline{
+//		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: dir}},
+//		Filters: []kio.Filter{&ls},
+//	}.Execute()
+//	results := ls.GetResults()
+var _ kio.Filter = &ListSetters{}
+
+// ListFrom discovers the setters of the resources read from r and writes
+// the results to w in the configured OutputFormat, one message per line.
+// The input is either a ResourceList, whose functionConfig is decoded into
+// the options of ls as done by the function, or a stream of resources.
+func (ls *ListSetters) ListFrom(r io.Reader, w io.Writer) error {
+	reader := &kio.ByteReader{Reader: r}
+	nodes, err := reader.Read()
+	if err != nil {
+		return errors.WrapPrefixf(err, "unable to read resources")
+	}
+	if err := Decode(reader.FunctionConfig, ls); err != nil {
+		return err
+	}
+	if _, err := ls.Filter(nodes); err != nil {
+		return err
+	}
+	return ls.writeResults(w)
+}
+
+// ListPackage discovers the setters of the package in dir, including its
+// Kptfile, and writes the results to w in the configured OutputFormat,
+// one message per line
+func (ls *ListSetters) ListPackage(dir string, w io.Writer) error {
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{&kio.LocalPackageReader{PackagePath: dir, MatchFilesGlob: append(kio.DefaultMatch, "Kptfile")}},
+		Filters: []kio.Filter{ls},
+	}.Execute()
+	if err != nil {
+		return err
+	}
+	return ls.writeResults(w)
+}
+
+// writeResults writes the changes in DryRun mode, the results of each
+// package if PerPackage is set or else the results, one message per line
+func (ls *ListSetters) writeResults(w io.Writer) error {
+	format := ls.FormatResults
+	if ls.DryRun {
+		format = ls.FormatChanges
+	} else if ls.PerPackage {
+		format = ls.FormatPackageResults
+	}
+	messages, err := format()
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if _, err := fmt.Fprintln(w, m); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
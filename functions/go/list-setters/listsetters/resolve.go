@@ -0,0 +1,61 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// SetterResolution reports whether the value of a setter is resolved
+type SetterResolution struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Resolved bool   `json:"resolved"`
+}
+
+func (r SetterResolution) String() string {
+	return fmt.Sprintf("Name: %s, Value: %s, Resolved: %t", r.Name, r.Value, r.Resolved)
+}
+
+// resolved returns true if none of the values of the result still
+// contains a setter reference, e.g. a placeholder value ${project}
+func (ls *ListSetters) resolved(r *Result) bool {
+	values := r.Values
+	if r.Type != ArraySetterType {
+		values = []string{r.Value}
+	}
+	for _, v := range values {
+		if ls.syntax().IsUnresolved(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatResolutions renders whether the value of each result is resolved in ResolveMode
+func (ls *ListSetters) formatResolutions(rs []*Result) ([]string, error) {
+	resolutions := make([]SetterResolution, len(rs))
+	for i, r := range rs {
+		resolutions[i] = SetterResolution{Name: r.Name, Value: r.Value, Resolved: r.Resolved}
+	}
+	switch ls.OutputFormat {
+	case JSONOutputFormat:
+		b, err := json.Marshal(resolutions)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		return []string{string(b)}, nil
+	case JSONLinesOutputFormat:
+		values := make([]interface{}, len(resolutions))
+		for i := range resolutions {
+			values[i] = resolutions[i]
+		}
+		return jsonLines(values)
+	}
+	out := make([]string, len(resolutions))
+	for i := range resolutions {
+		out[i] = resolutions[i].String()
+	}
+	return out, nil
+}
@@ -0,0 +1,181 @@
+package listsetters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+const (
+	// UnusedSetterRule is the SARIF rule of the setters with UnusedStatus
+	UnusedSetterRule = "unused-setter"
+
+	// UndeclaredSetterRule is the SARIF rule of the setters with UndeclaredStatus
+	UndeclaredSetterRule = "undeclared-setter"
+
+	// ConflictingValuesRule is the SARIF rule of the scalar setters
+	// parameterizing fields with different values
+	ConflictingValuesRule = "conflicting-setter-values"
+
+	// DiscoveryWarningRulePrefix prefixes the SARIF rules of the setter
+	// discovery warnings, which are followed by the WarningReason of the
+	// warning e.g. setter-discovery/NoPipeline
+	DiscoveryWarningRulePrefix = "setter-discovery/"
+
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// sarifRules are the rules reported in the SARIF log
+var sarifRules = []sarifRule{
+	{ID: UnusedSetterRule, ShortDescription: sarifMessage{"Setter declared in the Kptfile is not used by any resource"}},
+	{ID: UndeclaredSetterRule, ShortDescription: sarifMessage{"Setter used by resources is not declared in the Kptfile"}},
+	{ID: ConflictingValuesRule, ShortDescription: sarifMessage{"Fields parameterized by a scalar setter have conflicting values"}},
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// formatSARIF renders the unused, undeclared and conflicting setters of the
+// results and the setter discovery warnings as a SARIF log, e.g. for GitHub
+// code scanning. Unused setters are located at the Kptfile, the other setter
+// results at the fields of the setter and the warnings at their file.
+func (ls *ListSetters) formatSARIF(rs []*Result) (string, error) {
+	rules := append([]sarifRule{}, sarifRules...)
+	results := []sarifResult{}
+	for _, r := range rs {
+		switch r.Status {
+		case UnusedStatus:
+			var locs []sarifLocation
+			if ls.kfPath != "" {
+				locs = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: ls.kfPath}}}}
+			}
+			results = append(results, sarifResult{RuleID: UnusedSetterRule, Level: "error",
+				Message: sarifMessage{r.StatusMessage()}, Locations: locs})
+		case UndeclaredStatus:
+			results = append(results, sarifResult{RuleID: UndeclaredSetterRule, Level: "error",
+				Message: sarifMessage{r.StatusMessage()}, Locations: sarifLocations(ls.setterLocations(r))})
+		}
+		if s := ls.conflicting(r); s != nil {
+			results = append(results, sarifResult{RuleID: ConflictingValuesRule, Level: "warning",
+				Message: sarifMessage{conflictWarning(s).Error()}, Locations: sarifLocations(s.Locations)})
+		}
+	}
+	seen := map[string]bool{}
+	for _, w := range ls.Warnings {
+		// the conflicting values are reported at the fields of the setter above
+		if w.Reason == ConflictingValues {
+			continue
+		}
+		id := DiscoveryWarningRulePrefix + string(w.Reason)
+		if !seen[id] {
+			seen[id] = true
+			rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{fmt.Sprintf("Setter discovery warning %s", w.Reason)}})
+		}
+		var locs []sarifLocation
+		if w.File != "" {
+			locs = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: w.File}}}}
+		}
+		results = append(results, sarifResult{RuleID: id, Level: "warning",
+			Message: sarifMessage{w.Error()}, Locations: locs})
+	}
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "list-setters", Rules: rules}},
+			Results: results,
+		}},
+	}
+	b, err := json.Marshal(log)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return string(b), nil
+}
+
+// setterLocations returns the locations of the fields parameterized by the setter of the result
+func (ls *ListSetters) setterLocations(r *Result) []Location {
+	if r.Type == ArraySetterType {
+		if s, ok := ls.ArraySetters[r.Name]; ok {
+			return s.Locations
+		}
+		return nil
+	}
+	if s, ok := ls.ScalarSetters[r.Name]; ok {
+		return s.Locations
+	}
+	return nil
+}
+
+// sarifLocations converts the field locations to SARIF locations. The
+// positions of the fields are relative to their resource, so they're only
+// file-relative for the first document of the file and the region is omitted
+// for the later documents, which are only located at their file.
+func sarifLocations(locs []Location) []sarifLocation {
+	var out []sarifLocation
+	for _, l := range locs {
+		loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: l.File},
+		}}
+		if l.Document == 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line, StartColumn: l.Column}
+		}
+		out = append(out, loc)
+	}
+	return out
+}
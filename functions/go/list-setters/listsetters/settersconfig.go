@@ -0,0 +1,43 @@
+package listsetters
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// settersConfigData renders the results as the data block of an apply-setters
+// ConfigMap mapping each setter name to its value. The values of array setters
+// are rendered as YAML sequences in literal block scalars, which is the shape
+// apply-setters and getArraySetterValues expect. Setters with unresolved
+// values, which still contain a reference of the syntax, are skipped.
+func settersConfigData(rs []*Result, syntax SetterSyntax) (string, error) {
+	data := yaml.NewMapRNode(nil)
+	for _, r := range rs {
+		value := yaml.NewStringRNode(r.Value)
+		if r.Type == ArraySetterType {
+			seq, err := yaml.NewListRNode(r.Values...).String()
+			if err != nil {
+				return "", errors.Wrap(err)
+			}
+			value = yaml.NewStringRNode(seq)
+			value.YNode().Style = yaml.LiteralStyle
+		}
+		if syntax.IsUnresolved(value.YNode().Value) {
+			continue
+		}
+		if err := data.PipeE(yaml.SetField(r.Name, value)); err != nil {
+			return "", errors.Wrap(err)
+		}
+	}
+	out := yaml.NewMapRNode(nil)
+	if err := out.PipeE(yaml.SetField("data", data)); err != nil {
+		return "", errors.Wrap(err)
+	}
+	s, err := out.String()
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return strings.TrimSuffix(s, "\n"), nil
+}
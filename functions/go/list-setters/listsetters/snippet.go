@@ -0,0 +1,26 @@
+package listsetters
+
+import (
+	"io"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// ListSnippet discovers the setters of a single YAML document read from r,
+// e.g. a resource selected in an editor, and renders them in the configured
+// OutputFormat. The Kptfile isn't read and the document doesn't need to be a
+// complete resource or carry file annotations.
+func (ls *ListSetters) ListSnippet(r io.Reader) ([]string, error) {
+	nodes, err := (&kio.ByteReader{Reader: r, OmitReaderAnnotations: true}).Read()
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "unable to read snippet")
+	}
+	if len(nodes) != 1 {
+		return nil, errors.Errorf("expected a single YAML document in the snippet, found %d", len(nodes))
+	}
+	if _, err := ls.DiscoverSetters(nodes[0]); err != nil {
+		return nil, err
+	}
+	return ls.FormatResults()
+}
@@ -0,0 +1,35 @@
+package listsetters
+
+import (
+	"strings"
+)
+
+// DefaultSplitDelimiter is the default delimiter splitting the values
+// of the SplitSetters into array values
+const DefaultSplitDelimiter = ","
+
+// splitDelimiter returns the SplitDelimiter, DefaultSplitDelimiter if not set
+func (ls *ListSetters) splitDelimiter() string {
+	if ls.SplitDelimiter == "" {
+		return DefaultSplitDelimiter
+	}
+	return ls.SplitDelimiter
+}
+
+// splitValues returns the values of the scalar setter split by the
+// SplitDelimiter, it returns false if the setter isn't one of the
+// SplitSetters or its value doesn't contain the delimiter
+func (ls *ListSetters) splitValues(name, value string) ([]string, bool) {
+	split := false
+	for _, s := range ls.SplitSetters {
+		split = split || s == name
+	}
+	if !split || !strings.Contains(value, ls.splitDelimiter()) {
+		return nil, false
+	}
+	var out []string
+	for _, v := range strings.Split(value, ls.splitDelimiter()) {
+		out = append(out, strings.TrimSpace(v))
+	}
+	return out, true
+}
@@ -0,0 +1,74 @@
+package listsetters
+
+import (
+	"fmt"
+	"path"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Stats holds the statistics of the documents scanned by Filter
+type Stats struct {
+	// Documents is the number of input documents
+	Documents int `json:"documents"`
+
+	// Scanned is the number of documents whose fields were scanned for setters
+	Scanned int `json:"scanned"`
+
+	// Skipped is the number of documents which were not scanned as their root is
+	// not a mapping or they're excluded by the kinds, changedFiles or paths options
+	Skipped int `json:"skipped"`
+
+	// Truncated is the number of scanned documents with fields nested
+	// deeper than the maxDepth option, which were not scanned
+	Truncated int `json:"truncated"`
+
+	// KptfileFound is true if a Kptfile is part of the input
+	KptfileFound bool `json:"kptfileFound"`
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("Stats: Documents: %d, Scanned: %d, Skipped: %d, Truncated: %d, Kptfile found: %t",
+		s.Documents, s.Scanned, s.Skipped, s.Truncated, s.KptfileFound)
+}
+
+// add adds the statistics of the documents of a package
+func (s *Stats) add(o Stats) {
+	s.Documents += o.Documents
+	s.Scanned += o.Scanned
+	s.Skipped += o.Skipped
+	s.Truncated += o.Truncated
+	s.KptfileFound = s.KptfileFound || o.KptfileFound
+}
+
+// hasKptfile returns true if any of the nodes is a Kptfile
+func hasKptfile(nodes []*yaml.RNode) bool {
+	for _, node := range nodes {
+		if path.Base(nodePath(node)) == kptfilev1.KptFileName {
+			return true
+		}
+	}
+	return false
+}
+
+// skipReason returns why the node is not scanned for setters, it
+// returns an empty string if the node is scanned
+func (ls *ListSetters) skipReason(node *yaml.RNode) string {
+	if node.YNode().Kind != yaml.MappingNode {
+		return "document is skipped as its root is not a mapping"
+	}
+	if !ls.matchesKind(node) || !ls.matchesFile(node) {
+		return fmt.Sprintf("resource %s/%s is skipped as it doesn't match the %s or %s options",
+			node.GetKind(), node.GetName(), KindsKey, ChangedFilesKey)
+	}
+	if !ls.matchesNamespace(node) {
+		return fmt.Sprintf("resource %s/%s is skipped as it isn't in the namespace %q of the %s option",
+			node.GetKind(), node.GetName(), ls.Namespace, NamespaceKey)
+	}
+	if a := ls.ignoredAnnotation(node); a != "" {
+		return fmt.Sprintf("resource %s/%s is skipped as it has the annotation %s matching the %s option",
+			node.GetKind(), node.GetName(), a, IgnoreAnnotationsKey)
+	}
+	return ls.pathsSkipReason(node)
+}
@@ -0,0 +1,162 @@
+package listsetters
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// SetterSyntax identifies the setter references in setter patterns,
+// e.g. ${name} in `# kpt-set: ${name}-app`
+type SetterSyntax interface {
+	// Name is the value of the syntax option selecting the syntax
+	Name() string
+
+	// FindReferences returns the start and end indexes of
+	// the setter references in pattern, in order
+	FindReferences(pattern string) [][]int
+
+	// ParseReference returns the setter name and the default
+	// value of the setter reference, e.g. tag and latest for
+	// ${tag:-latest}, the default value is empty if not present
+	ParseReference(ref string) (name, def string)
+
+	// IsUnresolved returns true if value still contains a setter reference
+	IsUnresolved(value string) bool
+}
+
+// PatternValidator is implemented by the SetterSyntax implementations
+// which can detect malformed setter patterns
+type PatternValidator interface {
+	// ValidatePattern returns an error if the setter references
+	// of pattern are malformed e.g. unclosed
+	ValidatePattern(pattern string) error
+}
+
+// defaultSeparator separates the setter name from its default value e.g. ${tag:-latest}
+const defaultSeparator = ":-"
+
+// delimitedSyntax is a SetterSyntax whose setter references enclose the
+// setter name, optionally followed by a default value, in delimiters
+type delimitedSyntax struct {
+	// open and close are the delimiters of the setter references
+	open, close string
+
+	// re matches the setter references
+	re *regexp.Regexp
+}
+
+// newDelimitedSyntax returns the syntax of the setter references
+// enclosing the setter name in the open and close delimiters
+func newDelimitedSyntax(open, close string) *delimitedSyntax {
+	return &delimitedSyntax{
+		open:  open,
+		close: close,
+		re:    regexp.MustCompile(regexp.QuoteMeta(open) + `(.*?)` + regexp.QuoteMeta(close)),
+	}
+}
+
+var (
+	// DollarBraceSyntax is the default ${name} syntax of the setter references
+	DollarBraceSyntax SetterSyntax = newDelimitedSyntax("${", "}")
+
+	// DoubleBracketSyntax is the [[name]] syntax of the setter references
+	DoubleBracketSyntax SetterSyntax = newDelimitedSyntax("[[", "]]")
+
+	// DoubleBraceSyntax is the {{name}} syntax of the setter references
+	DoubleBraceSyntax SetterSyntax = newDelimitedSyntax("{{", "}}")
+)
+
+// setterSyntaxes returns the supported setter syntaxes
+func setterSyntaxes() []SetterSyntax {
+	return []SetterSyntax{DollarBraceSyntax, DoubleBracketSyntax, DoubleBraceSyntax}
+}
+
+// lookupSetterSyntax returns the supported setter syntax with the name
+func lookupSetterSyntax(name string) (SetterSyntax, error) {
+	for _, s := range setterSyntaxes() {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return nil, errors.Errorf("invalid %s %q, must be one of %q", SyntaxKey, name, setterSyntaxNames())
+}
+
+// setterSyntaxNames returns the names of the supported setter syntaxes
+func setterSyntaxNames() []string {
+	var out []string
+	for _, s := range setterSyntaxes() {
+		out = append(out, s.Name())
+	}
+	return out
+}
+
+func (s *delimitedSyntax) Name() string {
+	return s.open + s.close
+}
+
+func (s *delimitedSyntax) FindReferences(pattern string) [][]int {
+	return s.re.FindAllStringIndex(pattern, -1)
+}
+
+func (s *delimitedSyntax) ParseReference(ref string) (string, string) {
+	ref = strings.TrimSpace(ref)
+	ref = strings.TrimSuffix(strings.TrimPrefix(ref, s.open), s.close)
+	if i := strings.Index(ref, defaultSeparator); i >= 0 {
+		return ref[:i], ref[i+len(defaultSeparator):]
+	}
+	return ref, ""
+}
+
+func (s *delimitedSyntax) IsUnresolved(value string) bool {
+	return strings.Contains(value, s.open)
+}
+
+// ValidatePattern returns an error if a setter reference of the pattern isn't
+// closed, e.g. ${foo, or is nested in another one, e.g. ${foo-${bar}. Closing
+// delimiters outside of setter references are literal text e.g. {"a": "${b}"}.
+func (s *delimitedSyntax) ValidatePattern(pattern string) error {
+	start := -1
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], s.open):
+			if start >= 0 {
+				return errors.Errorf("setter reference %q is nested in %q", pattern[i:], pattern[start:])
+			}
+			start = i
+			i += len(s.open)
+		case start >= 0 && strings.HasPrefix(pattern[i:], s.close):
+			start = -1
+			i += len(s.close)
+		default:
+			i++
+		}
+	}
+	if start >= 0 {
+		return errors.Errorf("setter reference %q is not closed with %q", pattern[start:], s.close)
+	}
+	return nil
+}
+
+// syntaxOrDefault returns syntax, or DollarBraceSyntax if syntax is nil
+func syntaxOrDefault(syntax SetterSyntax) SetterSyntax {
+	if syntax == nil {
+		return DollarBraceSyntax
+	}
+	return syntax
+}
+
+// replaceReferences replaces the setter references in pattern with the
+// values returned by fn, which is called with each setter reference
+func replaceReferences(syntax SetterSyntax, pattern string, fn func(ref string) string) string {
+	var b strings.Builder
+	prev := 0
+	for _, loc := range syntax.FindReferences(pattern) {
+		b.WriteString(pattern[prev:loc[0]])
+		b.WriteString(fn(pattern[loc[0]:loc[1]]))
+		prev = loc[1]
+	}
+	b.WriteString(pattern[prev:])
+	return b.String()
+}
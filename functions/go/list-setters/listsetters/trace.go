@@ -0,0 +1,83 @@
+package listsetters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TraceEntry is a setter discovery decision made for a field,
+// recorded if Debug is set to diagnose why setters are not listed
+type TraceEntry struct {
+	// File is the file path of the resource
+	File string `json:"file"`
+
+	// Path is the path to the field, path elements are separated by '.'
+	Path string `json:"path"`
+
+	// Message describes the decision
+	Message string `json:"message"`
+}
+
+func (t TraceEntry) String() string {
+	if t.Path == "" {
+		return fmt.Sprintf("File: %s, %s", t.File, t.Message)
+	}
+	return fmt.Sprintf("File: %s, Path: %s, %s", t.File, t.Path, t.Message)
+}
+
+// trace records a decision made for the field at path of the resource
+func (c *fieldCollector) trace(res *resource, path, format string, args ...interface{}) {
+	if !c.debug {
+		return
+	}
+	c.out.trace = append(c.out.trace, &TraceEntry{File: res.filePath, Path: strings.TrimPrefix(path, "."), Message: fmt.Sprintf(format, args...)})
+}
+
+// traceComment records why the line comment of the field at path
+// isn't a setter comment, fields without comments are not recorded
+func (c *fieldCollector) traceComment(res *resource, path, comment string) {
+	if comment == "" {
+		return
+	}
+	identifier := c.setterComment
+	if identifier == "" {
+		identifier = SetterCommentIdentifier
+	}
+	if _, ok := trimSetterComment(comment, identifier); !ok {
+		c.trace(res, path, "comment %q doesn't start with the setter comment %q", comment, identifier)
+		return
+	}
+	if ignoredSetterComment(comment, identifier) {
+		c.trace(res, path, "setter comment %q is ignored by the %s directive", comment, IgnoreDirective)
+		return
+	}
+	c.trace(res, path, "setter comment %q has no setter pattern", comment)
+}
+
+// traceResolved records the setter values resolved from the value
+// of the field at path, or that the value doesn't match the pattern
+func (ls *ListSetters) traceResolved(res *resource, path, pattern, value string, values map[string]string) {
+	if !ls.Debug {
+		return
+	}
+	msg := fmt.Sprintf("value %q doesn't match the setter pattern %q", value, pattern)
+	if len(values) > 0 {
+		var resolved []string
+		for name, v := range values {
+			resolved = append(resolved, fmt.Sprintf("%s=%q", name, v))
+		}
+		sort.Strings(resolved)
+		msg = fmt.Sprintf("setter pattern %q resolved %s", pattern, strings.Join(resolved, ", "))
+	}
+	ls.trace(res, path, "%s", msg)
+}
+
+// trace records a decision made for the field at path of the resource
+// while adding the setters of the collected fields
+func (ls *ListSetters) trace(res *resource, path, format string, args ...interface{}) {
+	if !ls.Debug {
+		return
+	}
+	ls.Trace = append(ls.Trace, &TraceEntry{File: res.filePath, Path: strings.TrimPrefix(path, "."), Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,158 @@
+package listsetters
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// UntaggedField is a field without a setter comment holding the value of
+// setters, which is a candidate to be parameterized by one of them. It's
+// found by comparing values only, so it may be a false positive.
+type UntaggedField struct {
+	// File is the file path of the resource
+	File string
+
+	// Path is the path of the field in the resource e.g. spec.replicas
+	Path string
+
+	// Value is the value of the field
+	Value string
+
+	// Setters are the sorted names of the setters with the value
+	Setters []string
+}
+
+func (u *UntaggedField) Error() string {
+	return fmt.Sprintf("heuristic: candidate unparameterized field %s in %s has the value %q of setters [%s] "+
+		"but no setter comment, this may be a false positive", u.Path, u.File, u.Value, strings.Join(u.Setters, ", "))
+}
+
+// untaggedFinder is a visitor finding the scalar fields without
+// a setter comment which hold the value of a setter
+type untaggedFinder struct {
+	// setters maps the setter values to the sorted names of the setters
+	setters map[string][]string
+
+	// setterComment is the prefix of the line comments identifying setters
+	setterComment string
+
+	// syntax identifies the setter references
+	syntax SetterSyntax
+
+	// tagged holds the values of the mapping fields, and the elements of
+	// sequence values, parameterized by the setter comment on their key
+	tagged map[*yaml.Node]bool
+
+	// maxDepth is the maximum depth of the visited fields
+	maxDepth int
+
+	// found holds the untagged fields
+	found []*UntaggedField
+}
+
+func (f *untaggedFinder) depthLimit() int {
+	return f.maxDepth
+}
+
+func (f *untaggedFinder) visitTruncated(*yaml.RNode, string, *resource) error {
+	return nil
+}
+
+func (f *untaggedFinder) visitMapping(object *yaml.RNode, _ string, _ *resource) error {
+	return object.VisitFields(func(node *yaml.MapNode) error {
+		pattern := extractSetterPattern(node.Key.YNode().LineComment, f.setterComment, f.syntax)
+		if pattern == "" && node.Value.YNode().Kind != yaml.MappingNode {
+			pattern = extractHeadSetterPattern(node.Key.YNode().HeadComment, f.setterComment, f.syntax)
+		}
+		if pattern == "" && !hasIgnoredSetterComment(node.Key.YNode(), f.setterComment) && !hasIgnoredSetterComment(node.Value.YNode(), f.setterComment) {
+			return nil
+		}
+		f.tagged[node.Value.YNode()] = true
+		if node.Value.YNode().Kind == yaml.SequenceNode {
+			// the elements are parameterized by an array setter
+			for _, e := range node.Value.YNode().Content {
+				f.tagged[e] = true
+			}
+		}
+		return nil
+	})
+}
+
+func (f *untaggedFinder) visitScalar(object *yaml.RNode, p string, _ int, res *resource) error {
+	if f.tagged[object.YNode()] || fieldSetterPattern(object.YNode(), f.setterComment, f.syntax) != "" ||
+		hasIgnoredSetterComment(object.YNode(), f.setterComment) {
+		return nil
+	}
+	if strings.HasPrefix(p, ".metadata.annotations.") && strings.Contains(p, "config.kubernetes.io/") {
+		// annotations added by the orchestrator e.g. the file index,
+		// documented in the detectUntagged option
+		return nil
+	}
+	value := scalarValue(object)
+	if names, ok := f.setters[value]; ok {
+		f.found = append(f.found, &UntaggedField{File: res.filePath, Path: strings.TrimPrefix(p, "."), Value: value, Setters: names})
+	}
+	return nil
+}
+
+// findUntagged records the scalar fields of the nodes which hold the value of
+// a discovered scalar setter but have no setter comment in Untagged. The
+// Kptfile and the apply-setters configPath files are skipped as they declare
+// the setter values.
+func (ls *ListSetters) findUntagged(nodes []*yaml.RNode) error {
+	f := &untaggedFinder{setters: make(map[string][]string), setterComment: ls.SetterComment, syntax: ls.syntax(), tagged: make(map[*yaml.Node]bool),
+		maxDepth: ls.MaxDepth}
+	for name, s := range ls.ScalarSetters {
+		if s.Value == "" || ls.syntax().IsUnresolved(s.Value) || !ls.matchesName(name) {
+			continue
+		}
+		f.setters[s.Value] = append(f.setters[s.Value], name)
+	}
+	if len(f.setters) == 0 {
+		return nil
+	}
+	for _, names := range f.setters {
+		sort.Strings(names)
+	}
+	skip := setterConfigPaths(nodes)
+	for _, node := range nodes {
+		np := nodePath(node)
+		if path.Base(np) == kptfilev1.KptFileName || skip[np] || !ls.matchesKind(node) || !ls.matchesFile(node) || !ls.matchesPaths(node) || !ls.matchesNamespace(node) ||
+			ls.ignoredAnnotation(node) != "" {
+			continue
+		}
+		if err := accept(f, node, &resource{filePath: np}); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	ls.Untagged = append(ls.Untagged, f.found...)
+	return nil
+}
+
+// setterConfigPaths returns the paths of the apply-setters configPath
+// files declared in the Kptfiles of the nodes
+func setterConfigPaths(nodes []*yaml.RNode) map[string]bool {
+	out := make(map[string]bool)
+	for _, node := range nodes {
+		np := nodePath(node)
+		if path.Base(np) != kptfilev1.KptFileName {
+			continue
+		}
+		kf, err := decodeKptfile(node)
+		if err != nil || kf.Pipeline == nil {
+			continue
+		}
+		for _, fn := range kf.Pipeline.Mutators {
+			if strings.Contains(fn.Image, "apply-setters") && fn.ConfigPath != "" {
+				out[path.Join(path.Dir(np), slashPath(fn.ConfigPath))] = true
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package listsetters
+
+import (
+	goerrors "errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt-functions-sdk/go/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// findUpstreamSetters returns the setters declared in the Kptfiles of the
+// upstream packages of the root package which are part of the input,
+// following the upstream references of each of them in turn. Setters declared
+// closer to the root package override the ones declared further upstream.
+// The sources of the setters are prefixed with the path of the Kptfile
+// declaring them e.g. base/Kptfile pipeline.mutators[0].
+func (ls *ListSetters) findUpstreamSetters(nodes []*yaml.RNode) (map[string]string, map[string]string, error) {
+	kfNode, err := findKptfileNode(nodes)
+	if err != nil {
+		// already reported by the discovery of the Kptfile setters
+		return nil, nil, nil
+	}
+	var setters, sources map[string]string
+	visited := map[*yaml.RNode]bool{kfNode: true}
+	for {
+		kf, err := decodeKptfile(kfNode)
+		if err != nil {
+			return nil, nil, err
+		}
+		name, ref := upstreamPackage(kf)
+		if ref == "" {
+			return setters, sources, nil
+		}
+		upNode := findUpstreamKptfileNode(nodes, name, visited)
+		if upNode == nil {
+			if cycle := findUpstreamKptfileNode(nodes, name, nil); cycle != nil && cycle != kfNode {
+				// the upstream packages reference each other
+				return setters, sources, nil
+			}
+			ls.unresolvedUpstream = ref
+			return setters, sources, nil
+		}
+		visited[upNode] = true
+		kfPath := nodePath(upNode)
+		upSetters, upSources, err := kptfileSetters(nodes, upNode)
+		if err != nil {
+			var discoveryWarning *WarnSetterDiscovery
+			if !goerrors.As(err, &discoveryWarning) {
+				return nil, nil, err
+			}
+			ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: discoveryWarning.Reason, message: fmt.Sprintf("upstream %s: %s", kfPath, discoveryWarning.Error())})
+		}
+		for name, source := range upSources {
+			upSources[name] = fmt.Sprintf("%s %s", kfPath, source)
+		}
+		setters = mergeSetters(upSetters, setters)
+		sources = mergeSetters(upSources, sources)
+		kfNode = upNode
+	}
+}
+
+// upstreamPackage returns the name of the upstream package of kf and the
+// reference to it, the upstreamLock is preferred over the upstream as it
+// identifies the fetched commit. Empty strings are returned if kf doesn't
+// declare a git upstream.
+func upstreamPackage(kf *kptfilev1.KptFile) (string, string) {
+	var repo, dir, ref string
+	switch {
+	case kf.UpstreamLock != nil && kf.UpstreamLock.Git != nil:
+		repo, dir, ref = kf.UpstreamLock.Git.Repo, kf.UpstreamLock.Git.Directory, kf.UpstreamLock.Git.Commit
+		if ref == "" {
+			ref = kf.UpstreamLock.Git.Ref
+		}
+	case kf.Upstream != nil && kf.Upstream.Git != nil:
+		repo, dir, ref = kf.Upstream.Git.Repo, kf.Upstream.Git.Directory, kf.Upstream.Git.Ref
+	default:
+		return "", ""
+	}
+	// the package name is the name of the directory it's fetched from
+	name := path.Base(path.Clean("/" + dir))
+	if name == "/" {
+		name = strings.TrimSuffix(path.Base(repo), ".git")
+	}
+	out := strings.TrimSuffix(repo, "/") + "/" + strings.TrimPrefix(path.Clean("/"+dir), "/")
+	if ref != "" {
+		out += "@" + ref
+	}
+	return name, strings.TrimSuffix(out, "/")
+}
+
+// findUpstreamKptfileNode finds the Kptfile node of the package with the name
+// which is not visited yet, so that a package named after its upstream e.g.
+// fetched with kpt pkg get doesn't match itself. nil is returned if the nodes
+// don't include it.
+func findUpstreamKptfileNode(nodes []*yaml.RNode, name string, visited map[*yaml.RNode]bool) *yaml.RNode {
+	for _, node := range nodes {
+		np := nodePath(node)
+		if path.Base(np) == kptfilev1.KptFileName && node.GetName() == name && !visited[node] {
+			return node
+		}
+	}
+	return nil
+}
+
+// checkUnresolvedUpstream warns about the setters which are not declared in
+// any Kptfile of the input if the Kptfile of an upstream package is missing,
+// as they are likely declared in that Kptfile
+func (ls *ListSetters) checkUnresolvedUpstream() {
+	if ls.unresolvedUpstream == "" {
+		return
+	}
+	var names []string
+	for name, s := range ls.ScalarSetters {
+		if s.Source == "" {
+			names = append(names, name)
+		}
+	}
+	for name, s := range ls.ArraySetters {
+		if s.Source == "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	ls.Warnings = append(ls.Warnings, &WarnSetterDiscovery{Reason: UnresolvedUpstream, message: fmt.Sprintf(
+		"unable to find the Kptfile of the upstream package %s, setters [%s] could not be resolved",
+		ls.unresolvedUpstream, strings.Join(names, ", "))})
+}
@@ -0,0 +1,77 @@
+package listsetters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mismatch is a field whose value would be changed by re-applying the
+// discovered setter values with apply-setters, which indicates that the
+// setter pattern of the field isn't resolved as apply-setters applies it
+type Mismatch struct {
+	// File is the file path of the resource
+	File string
+
+	// Path is the path of the field in the resource e.g. spec.replicas
+	Path string
+
+	// Pattern is the setter pattern of the field
+	Pattern string
+
+	// Value is the current value of the field
+	Value string
+
+	// Applied is the value of the field after re-applying the setter values
+	Applied string
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("re-applying the setters to %s in %s would change %q to %q, the setter pattern %q isn't resolved consistently",
+		m.Path, m.File, m.Value, m.Applied, m.Pattern)
+}
+
+// verifiedField is a field checked by checkRoundTrip
+type verifiedField struct {
+	res   *resource
+	field setterField
+}
+
+// checkRoundTrip records the fields whose value would be changed by
+// re-applying the values of the discovered setters in Mismatches, the
+// values are substituted in the setter patterns of the fields as done by
+// apply-setters so that re-applying them is expected to be a no-op
+func (ls *ListSetters) checkRoundTrip() {
+	for _, v := range ls.verified {
+		f := v.field
+		var current, applied string
+		if f.array {
+			name, _ := ls.syntax().ParseReference(f.pattern)
+			s, ok := ls.ArraySetters[name]
+			if !ok {
+				continue
+			}
+			values := make([]string, len(f.elements))
+			for i := range f.elements {
+				values[i] = ls.elementValue(f.elements[i])
+			}
+			current = fmt.Sprintf("[%s]", strings.Join(values, ", "))
+			applied = fmt.Sprintf("[%s]", strings.Join(s.Values, ", "))
+		} else {
+			current = scalarValue(f.node)
+			applied = replaceReferences(ls.syntax(), f.pattern, func(ref string) string {
+				name, def := ls.syntax().ParseReference(ref)
+				if s, ok := ls.ScalarSetters[name]; ok {
+					return s.Value
+				}
+				if def != "" {
+					return def
+				}
+				return ref
+			})
+		}
+		if applied != current {
+			ls.Mismatches = append(ls.Mismatches, &Mismatch{File: v.res.filePath, Path: strings.TrimPrefix(f.path, "."),
+				Pattern: f.pattern, Value: current, Applied: applied})
+		}
+	}
+}
@@ -13,46 +13,71 @@ type visitor interface {
 	// visitScalar is called for each scalar field value on a resource
 	// node is the scalar field value
 	// path is the path to the field; path elements are separated by '.'
-	visitScalar(node *yaml.RNode, path string) error
+	// depth is the nesting depth of the field, 1 for the top level fields
+	// res is the resource the field belongs to
+	visitScalar(node *yaml.RNode, path string, depth int, res *resource) error
 
 	// visitMapping is called for each Mapping field value on a resource
 	// node is the mapping field value
 	// path is the path to the field
-	visitMapping(node *yaml.RNode, path string) error
+	// res is the resource the field belongs to
+	visitMapping(node *yaml.RNode, path string, res *resource) error
+
+	// visitTruncated is called for each field which is not visited
+	// as it's nested deeper than the depthLimit
+	visitTruncated(node *yaml.RNode, path string, res *resource) error
+
+	// depthLimit is the maximum depth of the visited fields,
+	// the depth is unlimited if it's not positive
+	depthLimit() int
 }
 
 // accept invokes the appropriate function on v for each field in object
-func accept(v visitor, object *yaml.RNode) error {
+func accept(v visitor, object *yaml.RNode, res *resource) error {
 	// get the OpenAPI for the type if it exists
-	return acceptImpl(v, object, "")
+	return acceptImpl(v, object, "", 0, res)
 }
 
-// acceptImpl implements accept using recursion
-func acceptImpl(v visitor, object *yaml.RNode, p string) error {
+// acceptImpl implements accept using recursion, depth is the depth of object
+func acceptImpl(v visitor, object *yaml.RNode, p string, depth int, res *resource) error {
 	switch object.YNode().Kind {
 	case yaml.DocumentNode:
 		// Traverse the child of the document
-		return accept(v, yaml.NewRNode(object.YNode()))
+		return acceptImpl(v, yaml.NewRNode(object.YNode()), p, depth, res)
 	case yaml.MappingNode:
-		if err := v.visitMapping(object, p); err != nil {
+		if err := v.visitMapping(object, p, res); err != nil {
 			return err
 		}
 		return object.VisitFields(func(node *yaml.MapNode) error {
 			// Traverse each field value
-			return acceptImpl(v, node.Value, p+"."+node.Key.YNode().Value)
+			return acceptChild(v, node.Value, p+"."+node.Key.YNode().Value, depth+1, res)
 		})
 	case yaml.SequenceNode:
 		return VisitElements(object, func(node *yaml.RNode, i int) error {
 			// Traverse each list element
-			return acceptImpl(v, node, p+fmt.Sprintf("[%d]", i))
+			return acceptChild(v, node, p+fmt.Sprintf("[%d]", i), depth+1, res)
 		})
 	case yaml.ScalarNode:
 		// Visit the scalar field
-		return v.visitScalar(object, p)
+		return v.visitScalar(object, p, depth, res)
+	case yaml.AliasNode:
+		// Aliases, including the merge keys <<: *x, share the content of the
+		// anchor which is visited at its definition, so that the setters
+		// are counted once as apply-setters sets the value once
+		return nil
 	}
 	return nil
 }
 
+// acceptChild traverses the child field of a mapping or sequence
+// unless it's nested deeper than the depthLimit of v
+func acceptChild(v visitor, object *yaml.RNode, p string, depth int, res *resource) error {
+	if limit := v.depthLimit(); limit > 0 && depth > limit {
+		return v.visitTruncated(object, p, res)
+	}
+	return acceptImpl(v, object, p, depth, res)
+}
+
 // VisitElements calls fn for each element in a SequenceNode.
 // Returns an error for non-SequenceNodes
 func VisitElements(rn *yaml.RNode, fn func(node *yaml.RNode, i int) error) error {
@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt-functions-catalog/functions/go/list-setters/generated"
 	"github.com/GoogleContainerTools/kpt-functions-catalog/functions/go/list-setters/listsetters"
+	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/kyaml/fn/framework"
 	"sigs.k8s.io/kustomize/kyaml/fn/framework/command"
 )
@@ -18,6 +20,7 @@ func main() {
 	cmd.Short = generated.ListSettersShort
 	cmd.Long = generated.ListSettersLong
 	cmd.Example = generated.ListSettersExamples
+	cmd.AddCommand(snippetCommand(), diffCommand())
 
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -25,6 +28,69 @@ func main() {
 	}
 }
 
+// snippetCommand returns the command listing the setters of a single YAML
+// document read from stdin, e.g. a resource selected in an editor
+func snippetCommand() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "snippet",
+		Short: "List the setters of a single YAML document read from stdin",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ls := listsetters.New()
+			ls.OutputFormat = format
+			messages, err := ls.ListSnippet(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			for _, m := range messages {
+				fmt.Fprintln(cmd.OutOrStdout(), m)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", listsetters.TextOutputFormat, "output format of the setters")
+	return cmd
+}
+
+// diffCommand returns the command listing the setters added, removed and
+// changed between two inventories rendered in the json format, e.g. of the
+// base and head renders of a package in a pull request
+func diffCommand() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "diff BASE HEAD",
+		Short: "List the setters changed between two inventories rendered in the json format",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var inventories [2][]*listsetters.Result
+			for i, file := range args {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				inventories[i], err = listsetters.ReadResults(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("%s: %w", file, err)
+				}
+			}
+			ls := listsetters.New()
+			ls.OutputFormat = format
+			messages, err := ls.FormatDiff(listsetters.Diff(inventories[0], inventories[1]))
+			if err != nil {
+				return err
+			}
+			for _, m := range messages {
+				fmt.Fprintln(cmd.OutOrStdout(), m)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", listsetters.TextOutputFormat, "output format of the diff")
+	return cmd
+}
+
 type ListSettersProcessor struct{}
 
 func (lsp *ListSettersProcessor) Process(resourceList *framework.ResourceList) error {
@@ -42,28 +108,141 @@ func (lsp *ListSettersProcessor) Process(resourceList *framework.ResourceList) e
 
 func run(resourceList *framework.ResourceList) ([]framework.ResultItem, error) {
 	ls := listsetters.New()
-	_, err := ls.Filter(resourceList.Items)
+	err := listsetters.Decode(resourceList.FunctionConfig, &ls)
+	if err != nil {
+		return nil, err
+	}
+	resourceList.Items, err = ls.Filter(resourceList.Items)
 	if err != nil {
 		return nil, err
 	}
-	resultItems, err := resultsToItems(ls)
+	var resultItems []framework.ResultItem
+	if ls.DryRun {
+		resultItems, err = changesToItems(ls)
+	} else {
+		resultItems, err = resultsToItems(ls)
+	}
 	if err != nil {
 		return nil, err
 	}
+	resultItems = append(resultItems, warningsToItems(ls)...)
+	// the violations only fail the function in the constraints errorOn category
+	for _, v := range ls.Violations {
+		resultItems = append(resultItems, getErrorItem(v.Error(), framework.Warning)...)
+	}
+	// the mismatches only fail the function in the mismatch errorOn category
+	for _, m := range ls.Mismatches {
+		resultItems = append(resultItems, getErrorItem(m.Error(), framework.Warning)...)
+	}
+	// the untagged fields are heuristic, they only fail the
+	// function in the untagged errorOn category
+	for _, u := range ls.Untagged {
+		resultItems = append(resultItems, getErrorItem(u.Error(), framework.Warning)...)
+	}
+	for _, t := range ls.Trace {
+		resultItems = append(resultItems, getErrorItem(t.String(), framework.Info)...)
+	}
+	if ls.Namespace != "" {
+		resultItems = append(resultItems, getErrorItem(fmt.Sprintf(
+			"setter discovery is scoped to the namespace %s", ls.Namespace), framework.Info)...)
+	}
+	if len(ls.ChangedFiles) > 0 {
+		resultItems = append(resultItems, getErrorItem(fmt.Sprintf(
+			"setter discovery is scoped to the changed files: %s", strings.Join(ls.ChangedFiles, ", ")), framework.Info)...)
+	}
+	if !ls.IncludeKptfile {
+		resultItems = append(resultItems, getErrorItem(
+			"Kptfile discovery is disabled, setters are only discovered from resource comments", framework.Info)...)
+	}
 	return resultItems, nil
 }
 
 // resultsToItems converts the listsetters results to
-// equivalent items([]framework.Item)
+// equivalent items([]framework.Item), the statuses of the setters
+// are warnings as they only fail the function in their errorOn category
 func resultsToItems(sr listsetters.ListSetters) ([]framework.ResultItem, error) {
 	var items []framework.ResultItem
-	rs := sr.GetResults()
-	if len(rs) == 0 {
-		return getErrorItem("no setters found", framework.Warning), nil
+	if sr.Summary {
+		summary, err := sr.FormatSummary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, framework.ResultItem{
+			Message: summary,
+		})
+	}
+	if sr.ReportStats {
+		stats, err := sr.FormatStats()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, framework.ResultItem{
+			Message: stats,
+		})
+	}
+	results := sr.GetResults()
+	format := sr.FormatResults
+	if sr.PerPackage {
+		results = nil
+		for _, pr := range sr.GetPackageResults() {
+			results = append(results, pr.Setters...)
+		}
+		format = sr.FormatPackageResults
+	}
+	if len(results) == 0 && sr.OutputFormat == listsetters.TextOutputFormat {
+		return append(items, getErrorItem("no setters found", framework.Warning)...), nil
+	}
+	messages, err := format()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range messages {
+		items = append(items, framework.ResultItem{
+			Message: m,
+		})
+	}
+	for _, r := range results {
+		msg := r.StatusMessage()
+		if msg == "" {
+			continue
+		}
+		items = append(items, framework.ResultItem{
+			Message:  msg,
+			Severity: framework.Warning,
+		})
+	}
+	return items, nil
+}
+
+// warningsToItems converts the setter discovery warnings to items with the
+// warning severity, the file and field are set if the warning is about them
+func warningsToItems(sr listsetters.ListSetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	for _, w := range sr.Warnings {
+		items = append(items, framework.ResultItem{
+			Message:  w.Error(),
+			Severity: framework.Warning,
+			File:     framework.File{Path: w.File},
+			Field:    framework.Field{Path: w.Path},
+		})
+	}
+	return items
+}
+
+// changesToItems converts the dry-run changes to
+// equivalent items([]framework.Item)
+func changesToItems(sr listsetters.ListSetters) ([]framework.ResultItem, error) {
+	var items []framework.ResultItem
+	if len(sr.Changes) == 0 && sr.OutputFormat == listsetters.TextOutputFormat {
+		return getErrorItem("no fields would be changed", framework.Info), nil
+	}
+	messages, err := sr.FormatChanges()
+	if err != nil {
+		return nil, err
 	}
-	for _, r := range rs {
+	for _, m := range messages {
 		items = append(items, framework.ResultItem{
-			Message: r.String(),
+			Message: m,
 		})
 	}
 	return items, nil